@@ -0,0 +1,188 @@
+package gogl
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// an active vertex attribute of a linked program
+type AttributeInfo struct {
+	Name     string
+	Type     uint32 // the glsl type e.g. gl.FLOAT_VEC3
+	Size     int32  // the array length or 1 if it isn't an array
+	Location int32
+}
+
+// queries every active vertex attribute of a linked program
+// built in inputs like gl_VertexID are left out
+func ReflectAttributes(id ProgramID) []AttributeInfo {
+	var count, maxLength int32
+	ogl.GetProgramiv(uint32(id), gl.ACTIVE_ATTRIBUTES, &count)
+	ogl.GetProgramiv(uint32(id), gl.ACTIVE_ATTRIBUTE_MAX_LENGTH, &maxLength)
+
+	attributes := make([]AttributeInfo, 0, count)
+	nameBuf := make([]uint8, maxLength+1)
+	for i := uint32(0); i < uint32(count); i++ {
+		var length, size int32
+		var xtype uint32
+		ogl.GetActiveAttrib(uint32(id), i, int32(len(nameBuf)), &length, &size, &xtype, &nameBuf[0])
+
+		name := string(nameBuf[:length])
+		if strings.HasPrefix(name, "gl_") {
+			continue
+		}
+		attributes = append(attributes, AttributeInfo{
+			Name:     name,
+			Type:     xtype,
+			Size:     size,
+			Location: ogl.GetAttribLocation(uint32(id), gl.Str(name+"\x00")),
+		})
+	}
+	return attributes
+}
+
+// the active vertex attributes of the current program
+func (p *program) Attributes() []AttributeInfo {
+	return p.attributes
+}
+
+// the location of an active attribute or -1
+func (p *program) AttributeLocation(name string) int32 {
+	for _, a := range p.attributes {
+		if a.Name == name {
+			return a.Location
+		}
+	}
+	return -1
+}
+
+// the number of components read from each location, how many
+// locations the type uses and whether it's an integer type
+func attributeShape(xtype uint32) (components, locations int32, integer bool) {
+	switch xtype {
+	case gl.FLOAT:
+		return 1, 1, false
+	case gl.FLOAT_VEC2:
+		return 2, 1, false
+	case gl.FLOAT_VEC3:
+		return 3, 1, false
+	case gl.FLOAT_VEC4:
+		return 4, 1, false
+	case gl.INT, gl.UNSIGNED_INT:
+		return 1, 1, true
+	case gl.INT_VEC2, gl.UNSIGNED_INT_VEC2:
+		return 2, 1, true
+	case gl.INT_VEC3, gl.UNSIGNED_INT_VEC3:
+		return 3, 1, true
+	case gl.INT_VEC4, gl.UNSIGNED_INT_VEC4:
+		return 4, 1, true
+	case gl.FLOAT_MAT2:
+		return 2, 2, false
+	case gl.FLOAT_MAT3:
+		return 3, 3, false
+	case gl.FLOAT_MAT4:
+		return 4, 4, false
+	case gl.FLOAT_MAT2x3:
+		return 3, 2, false
+	case gl.FLOAT_MAT2x4:
+		return 4, 2, false
+	case gl.FLOAT_MAT3x2:
+		return 2, 3, false
+	case gl.FLOAT_MAT3x4:
+		return 4, 3, false
+	case gl.FLOAT_MAT4x2:
+		return 2, 4, false
+	case gl.FLOAT_MAT4x3:
+		return 3, 4, false
+	}
+	return 0, 1, false
+}
+
+// a vertex attribute set up by a BufferLoader
+type VertexAttribute struct {
+	Name       string // optional, used to find the location in BindToShader
+	Location   uint32
+	Components int32
+	Type       uint32 // the component type in the buffer e.g. gl.FLOAT
+	Normalized bool
+	// read with glVertexAttribIPointer as an int or uint in the shader
+	Integer bool
+	Stride  int32
+	Offset  uintptr
+	// 0 to advance every vertex, 1 to advance every instance
+	Divisor uint32
+
+	buffer BufferID
+	// matrices use a location per column, all with the name of the matrix
+	column uint32
+}
+
+// points the attribute at location in the currently bound vertex array
+func (a VertexAttribute) apply(location uint32) {
+	ogl.BindBuffer(gl.ARRAY_BUFFER, uint32(a.buffer))
+	if a.Integer {
+		ogl.VertexAttribIPointerWithOffset(location, a.Components, a.Type, a.Stride, a.Offset)
+	} else {
+		ogl.VertexAttribPointerWithOffset(location, a.Components, a.Type, a.Normalized, a.Stride, a.Offset)
+	}
+	ogl.VertexAttribDivisor(location, a.Divisor)
+	ogl.EnableVertexAttribArray(location)
+}
+
+// returned when a vertex layout doesn't match the inputs of a shader
+type AttributeError struct {
+	Name     string
+	Location int32
+	Reason   string
+}
+
+func (e *AttributeError) Error() string {
+	return fmt.Sprintf("vertex attribute %s (location %d): %s", e.Name, e.Location, e.Reason)
+}
+
+// checks that every attribute the shader reads is in the layout
+// with the same number of components and the same float or integer type
+func ValidateAttributes(layout []VertexAttribute, shader Shader) error {
+	byLocation := make(map[uint32]VertexAttribute, len(layout))
+	for _, a := range layout {
+		byLocation[a.Location] = a
+	}
+
+	var errs []error
+	for _, input := range shader.Attributes() {
+		for _, a := range layout {
+			if a.Name == input.Name && a.column == 0 && int32(a.Location) != input.Location {
+				errs = append(errs, &AttributeError{input.Name, input.Location, fmt.Sprintf(
+					"the layout puts it at location %d, bind the layout to the shader by name to fix it", a.Location)})
+			}
+		}
+
+		components, locations, integer := attributeShape(input.Type)
+		for i := int32(0); i < locations*input.Size; i++ {
+			location := uint32(input.Location + i)
+			a, ok := byLocation[location]
+			if !ok {
+				errs = append(errs, &AttributeError{input.Name, int32(location),
+					"the shader reads it but the vertex layout has nothing at that location"})
+				continue
+			}
+			if a.Components != components {
+				errs = append(errs, &AttributeError{input.Name, int32(location), fmt.Sprintf(
+					"the shader reads %d components but the layout has %d", components, a.Components)})
+			}
+			if a.Integer != integer {
+				if integer {
+					errs = append(errs, &AttributeError{input.Name, int32(location),
+						"the shader reads an integer type but the layout gives floats"})
+				} else {
+					errs = append(errs, &AttributeError{input.Name, int32(location),
+						"the shader reads a float type but the layout gives integers"})
+				}
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -14,21 +14,44 @@ type BufferID uint32
 // e.g. VertexBufferObject or NormalArrayObject
 func GenBindBuffer(target uint32) BufferID {
 	var buffer uint32
-	gl.GenBuffers(1, &buffer)
-	gl.BindBuffer(target, buffer)
+	ogl.GenBuffers(1, &buffer)
+	ogl.BindBuffer(target, buffer)
+	trackResource(ResourceBuffer, buffer)
 	return BufferID(buffer)
 }
 
 // used for generating and binding vertex buffers
 func GenBindVertexArray() BufferID {
 	var VAO uint32
-	gl.GenVertexArrays(1, &VAO)
-	gl.BindVertexArray(VAO)
+	ogl.GenVertexArrays(1, &VAO)
+	ogl.BindVertexArray(VAO)
+	trackResource(ResourceVertexArray, VAO)
 	return BufferID(VAO)
 }
 
 func BindVertexArray(id BufferID) {
-	gl.BindVertexArray(uint32(id))
+	ogl.BindVertexArray(uint32(id))
+}
+
+// frees a buffer made with GenBindBuffer, deleting 0 does nothing
+func (id BufferID) Delete() {
+	if id == 0 {
+		return
+	}
+	buffer := uint32(id)
+	ogl.DeleteBuffers(1, &buffer)
+	untrackResource(ResourceBuffer, buffer)
+}
+
+// frees a vertex array made with GenBindVertexArray
+// as vertex arrays share the BufferID type with buffers
+func (id BufferID) DeleteVertexArray() {
+	if id == 0 {
+		return
+	}
+	vao := uint32(id)
+	ogl.DeleteVertexArrays(1, &vao)
+	untrackResource(ResourceVertexArray, vao)
 }
 
 // used for initialising the target with the go array at data
@@ -37,15 +60,29 @@ func BufferData[T any](target uint32, data []T, usage uint32) {
 	var v T
 	dataTypeSize := unsafe.Sizeof(v)
 
-	gl.BufferData(target, len(data)*int(dataTypeSize), gl.Ptr(data), usage)
+	if len(data) == 0 {
+		//gl.Ptr can't take the address of an empty slice
+		ogl.BufferData(target, 0, nil, usage)
+		return
+	}
+	ogl.BufferData(target, len(data)*int(dataTypeSize), gl.Ptr(data), usage)
+}
+
+// the go types that can be used as vertex attribute components
+type VertexComponent interface {
+	~int8 | ~uint8 | ~int16 | ~uint16 | ~int32 | ~uint32 | ~float32 | ~float64
 }
 
-type BufferLayout[T any] struct {
-	data     []T
-	segments []int32
+type BufferLayout[T VertexComponent] struct {
+	data       []T
+	segments   []int32
+	names      []string
+	normalized bool
+	integer    bool
+	usage      uint32
 }
 
-func NewBufferLayout[T any](segments []int32, data []T) BufferLayout[T] {
+func NewBufferLayout[T VertexComponent](segments []int32, data []T) BufferLayout[T] {
 	b := BufferLayout[T]{
 		data:     data,
 		segments: segments,
@@ -53,6 +90,48 @@ func NewBufferLayout[T any](segments []int32, data []T) BufferLayout[T] {
 	return b
 }
 
+// names the attributes of each segment so they can
+// be bound to a shader by name with BindToShader
+func (b BufferLayout[T]) Named(names ...string) BufferLayout[T] {
+	b.names = names
+	return b
+}
+
+// maps integer components to 0-1, or -1-1 for signed types,
+// when the shader reads them as floats e.g. colours packed as uint8
+func (b BufferLayout[T]) Normalized() BufferLayout[T] {
+	b.normalized = true
+	return b
+}
+
+// passes integer components to the shader unconverted with glVertexAttribIPointer
+// for int and uint inputs e.g. bone indices
+func (b BufferLayout[T]) Integer() BufferLayout[T] {
+	b.integer = true
+	return b
+}
+
+// the usage hint the data is uploaded with, gl.STATIC_DRAW by default
+// use gl.DYNAMIC_DRAW for data that's updated later through ExistingBuffer
+func (b BufferLayout[T]) Usage(usage uint32) BufferLayout[T] {
+	b.usage = usage
+	return b
+}
+
+func (b BufferLayout[T]) getUsage() uint32 {
+	if b.usage == 0 {
+		return gl.STATIC_DRAW
+	}
+	return b.usage
+}
+
+func (b BufferLayout[T]) name(index int) string {
+	if index < len(b.names) {
+		return b.names[index]
+	}
+	return ""
+}
+
 func (b BufferLayout[T]) dataSize() int32 {
 	var v T
 	dataTypeSize := unsafe.Sizeof(v)
@@ -77,16 +156,35 @@ func (b BufferLayout[T]) getXType() uint32 {
 	var v T
 	i := reflect.TypeOf(v).Kind()
 	switch i {
+	case reflect.Int8:
+		return gl.BYTE
+	case reflect.Uint8:
+		return gl.UNSIGNED_BYTE
+	case reflect.Int16:
+		return gl.SHORT
+	case reflect.Uint16:
+		return gl.UNSIGNED_SHORT
+	case reflect.Int32:
+		return gl.INT
+	case reflect.Uint32:
+		return gl.UNSIGNED_INT
 	case reflect.Float32:
 		return gl.FLOAT
+	case reflect.Float64:
+		return gl.DOUBLE
 	default:
 		panic(fmt.Errorf("unsupported type (%v) for generating xtype", i))
-		//I've only implemented the one's I've had to use
 	}
 }
 
+func (b BufferLayout[T]) isFloat() bool {
+	xtype := b.getXType()
+	return xtype == gl.FLOAT || xtype == gl.DOUBLE
+}
+
 type BufferLoader struct {
 	layoutIndex uint32
+	attributes  []VertexAttribute
 }
 
 func NewBufferLoader() *BufferLoader {
@@ -95,19 +193,117 @@ func NewBufferLoader() *BufferLoader {
 }
 
 func (b *BufferLoader) BuildFloatBuffer(id BufferID, layout BufferLayout[float32]) {
-	BufferData(gl.ARRAY_BUFFER, layout.data, gl.STATIC_DRAW)
+	BuildBuffer(b, id, layout)
+}
+
+// uploads the layout's data into the bound array buffer and adds its
+// attributes to the vertex array at id after the loader's previous ones
+// it's a function rather than a method as methods can't have type parameters
+func BuildBuffer[T VertexComponent](b *BufferLoader, id BufferID, layout BufferLayout[T]) {
+	if layout.integer && layout.isFloat() {
+		panic(fmt.Errorf("integer attributes need integer components not %T", layout.data))
+	}
+
+	BufferData(gl.ARRAY_BUFFER, layout.data, layout.getUsage())
 
 	BindVertexArray(id)
 
+	var buffer int32
+	ogl.GetIntegerv(gl.ARRAY_BUFFER_BINDING, &buffer)
+
 	for i, s := range layout.segments {
-		index := b.layoutIndex + uint32(i)
-		gl.VertexAttribPointerWithOffset(index, s, layout.getXType(), false, layout.calcStride(), layout.offset(i))
-		gl.EnableVertexAttribArray(index)
+		attribute := VertexAttribute{
+			Name:       layout.name(i),
+			Location:   b.layoutIndex + uint32(i),
+			Components: s,
+			Type:       layout.getXType(),
+			Normalized: layout.normalized && !layout.isFloat(),
+			Integer:    layout.integer,
+			Stride:     layout.calcStride(),
+			Offset:     layout.offset(i),
+			buffer:     BufferID(buffer),
+		}
+		attribute.apply(attribute.Location)
+		b.attributes = append(b.attributes, attribute)
 	}
 
 	b.layoutIndex += uint32(len(layout.segments))
 }
 
+// every attribute the loader has set up
+func (b *BufferLoader) Attributes() []VertexAttribute {
+	return b.attributes
+}
+
+// moves every named attribute of the vertex array at id to the location
+// the shader has for that name, then checks the layout against the shader
+// the attributes of a shader can move when it reloads so bind it again after
+//
+// nothing is changed if two attributes end up at the same location or
+// the layout doesn't match the shader, the vertex array keeps its old layout
+func (b *BufferLoader) BindToShader(id BufferID, shader Shader) error {
+	moved := append([]VertexAttribute(nil), b.attributes...)
+	used := make(map[uint32]string)
+	var bound []VertexAttribute
+	for i, a := range moved {
+		if a.Name != "" {
+			location := shader.AttributeLocation(a.Name)
+			if location < 0 {
+				continue //not in the shader or optimised out
+			}
+			a.Location = uint32(location) + a.column
+			moved[i] = a
+		}
+
+		if other, ok := used[a.Location]; ok {
+			return &AttributeError{a.Name, int32(a.Location), fmt.Sprintf("%s is already at that location", other)}
+		}
+		used[a.Location] = a.Name
+		bound = append(bound, a)
+	}
+	if err := ValidateAttributes(bound, shader); err != nil {
+		return err
+	}
+
+	BindVertexArray(id)
+	for _, a := range b.attributes {
+		ogl.DisableVertexAttribArray(a.Location)
+	}
+	for _, a := range bound {
+		a.apply(a.Location)
+	}
+	b.attributes = moved
+	return nil
+}
+
+// checks the attributes against the inputs of the shader without moving them
+func (b *BufferLoader) Validate(shader Shader) error {
+	return ValidateAttributes(b.attributes, shader)
+}
+
+// used for uploading 16 bit indices into an element buffer
+// owned by the vertex array at id
+func (b *BufferLoader) BuildUint16IndexBuffer(id BufferID, indices []uint16) BufferID {
+	return buildIndexBuffer(id, indices)
+}
+
+// used for uploading 32 bit indices into an element buffer
+// owned by the vertex array at id
+func (b *BufferLoader) BuildUint32IndexBuffer(id BufferID, indices []uint32) BufferID {
+	return buildIndexBuffer(id, indices)
+}
+
+// the element array binding is part of the vertex array state
+// so the VAO has to be bound before the element buffer is
+func buildIndexBuffer[T uint16 | uint32](id BufferID, indices []T) BufferID {
+	BindVertexArray(id)
+
+	ebo := GenBindBuffer(gl.ELEMENT_ARRAY_BUFFER)
+	BufferData(gl.ELEMENT_ARRAY_BUFFER, indices, gl.STATIC_DRAW)
+
+	return ebo
+}
+
 // VAO := helpers.GenBindVertexArray()
 // helpers.BufferData(gl.ARRAY_BUFFER, verticies, gl.STATIC_DRAW)
 
@@ -0,0 +1,194 @@
+package gogl
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestBindToShaderMovesAttributes(t *testing.T) {
+	fake, vao, loader := testBufferLoader(t, false)
+	shader := testShader(t, `#version 330 core
+layout (location = 1) in vec3 aPos;
+layout (location = 0) in vec2 aUV;
+void main() { gl_Position = vec4(aPos + vec3(aUV, 0), 1); }`)
+
+	if err := loader.BindToShader(vao, shader); err != nil {
+		t.Fatal(err)
+	}
+
+	attributes := fake.VertexArray(vao).Attributes
+	pos, uv := attributes[1], attributes[0]
+	if pos == nil || !pos.Enabled || pos.Components != 3 || pos.Offset != 0 {
+		t.Errorf("aPos at location 1 = %+v", pos)
+	}
+	if uv == nil || !uv.Enabled || uv.Components != 2 || uv.Offset != 12 {
+		t.Errorf("aUV at location 0 = %+v", uv)
+	}
+	if err := loader.Validate(shader); err != nil {
+		t.Errorf("the moved layout doesn't validate: %v", err)
+	}
+}
+
+func TestBindToShaderFailureKeepsLayout(t *testing.T) {
+	tests := []struct {
+		name         string
+		vertexShader string
+		wantError    string
+	}{
+		{
+			name: "location taken by an unnamed attribute",
+			vertexShader: `#version 330 core
+layout (location = 2) in vec3 aPos;
+layout (location = 1) in vec2 aUV;
+void main() { gl_Position = vec4(aPos + vec3(aUV, 0), 1); }`,
+			wantError: "is already at that location",
+		},
+		{
+			name: "layout doesn't match the shader",
+			vertexShader: `#version 330 core
+layout (location = 1) in vec3 aPos;
+layout (location = 0) in ivec2 aUV;
+void main() { gl_Position = vec4(aPos + vec3(aUV, 0), 1); }`,
+			wantError: "the shader reads an integer type but the layout gives floats",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, vao, loader := testBufferLoader(t, true)
+			shader := testShader(t, test.vertexShader)
+
+			before := slices.Clone(loader.Attributes())
+			fake.Reset()
+
+			err := loader.BindToShader(vao, shader)
+			var attributeErr *AttributeError
+			if !errors.As(err, &attributeErr) || !strings.Contains(err.Error(), test.wantError) {
+				t.Fatalf("got error %v, want one containing %q", err, test.wantError)
+			}
+
+			if !slices.Equal(loader.Attributes(), before) {
+				t.Errorf("attributes changed to %+v", loader.Attributes())
+			}
+			if len(fake.Calls) != 0 {
+				t.Errorf("the vertex array was changed with %v", fake.Calls)
+			}
+			for location := uint32(0); location < 3; location++ {
+				if a := fake.VertexArray(vao).Attributes[location]; a == nil || !a.Enabled {
+					t.Errorf("attribute %d was disabled", location)
+				}
+			}
+		})
+	}
+}
+
+// builds a layout of a vec3 and a vec2 and returns the attribute
+// of the vec2 along with the bytes uploaded for the whole buffer
+func buildTestLayout[T VertexComponent](t *testing.T, layout func(BufferLayout[T]) BufferLayout[T]) (*FakeAttribute, []byte) {
+	t.Helper()
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	vao := GenBindVertexArray()
+	buffer := GenBindBuffer(gl.ARRAY_BUFFER)
+	BuildBuffer(NewBufferLoader(), vao, layout(NewBufferLayout([]int32{3, 2}, make([]T, 5))))
+	return fake.VertexArray(vao).Attributes[1], fake.Buffer(buffer).Data
+}
+
+func TestBuildBufferComponentTypes(t *testing.T) {
+	tests := []struct {
+		name  string
+		build func(t *testing.T) (*FakeAttribute, []byte)
+		want  FakeAttribute
+	}{
+		{
+			name: "int8",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, func(l BufferLayout[int8]) BufferLayout[int8] { return l })
+			},
+			want: FakeAttribute{Components: 2, Type: gl.BYTE, Stride: 5, Offset: 3},
+		},
+		{
+			name: "normalized uint8",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, BufferLayout[uint8].Normalized)
+			},
+			want: FakeAttribute{Components: 2, Type: gl.UNSIGNED_BYTE, Normalized: true, Stride: 5, Offset: 3},
+		},
+		{
+			name: "normalized int16",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, BufferLayout[int16].Normalized)
+			},
+			want: FakeAttribute{Components: 2, Type: gl.SHORT, Normalized: true, Stride: 10, Offset: 6},
+		},
+		{
+			name: "integer uint16",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, BufferLayout[uint16].Integer)
+			},
+			want: FakeAttribute{Components: 2, Type: gl.UNSIGNED_SHORT, Integer: true, Stride: 10, Offset: 6},
+		},
+		{
+			name: "integer int32",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, BufferLayout[int32].Integer)
+			},
+			want: FakeAttribute{Components: 2, Type: gl.INT, Integer: true, Stride: 20, Offset: 12},
+		},
+		{
+			name: "uint32",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, func(l BufferLayout[uint32]) BufferLayout[uint32] { return l })
+			},
+			want: FakeAttribute{Components: 2, Type: gl.UNSIGNED_INT, Stride: 20, Offset: 12},
+		},
+		{
+			name: "normalized floats stay unnormalized",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, BufferLayout[float32].Normalized)
+			},
+			want: FakeAttribute{Components: 2, Type: gl.FLOAT, Stride: 20, Offset: 12},
+		},
+		{
+			name: "float64",
+			build: func(t *testing.T) (*FakeAttribute, []byte) {
+				return buildTestLayout(t, func(l BufferLayout[float64]) BufferLayout[float64] { return l })
+			},
+			want: FakeAttribute{Components: 2, Type: gl.DOUBLE, Stride: 40, Offset: 24},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, data := test.build(t)
+			if a == nil {
+				t.Fatal("the second attribute wasn't set")
+			}
+			got := *a
+			got.Buffer, got.Enabled = 0, false
+			if got != test.want {
+				t.Errorf("attribute = %+v, want %+v", got, test.want)
+			}
+			if !a.Enabled {
+				t.Error("the attribute wasn't enabled")
+			}
+			if len(data) != int(test.want.Stride) {
+				t.Errorf("uploaded %d bytes for one vertex of %d", len(data), test.want.Stride)
+			}
+		})
+	}
+}
+
+func TestBuildBufferIntegerFloats(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("integer float components didn't panic")
+		}
+	}()
+	buildTestLayout(t, BufferLayout[float32].Integer)
+}
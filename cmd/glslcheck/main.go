@@ -0,0 +1,176 @@
+// Command glslcheck checks the shaders of a program for errors without a gpu.
+//
+// It expands #include directives the same way gogl does, then checks each
+// stage for syntax errors and checks that every input of a stage is written
+// by the stage before it. If glslangValidator is installed it's also used to
+// compile each stage.
+//
+// Usage:
+//
+//	glslcheck [flags] [shader files...]
+//
+// Stages are given with -vert, -geom and -frag or guessed from the extension
+// of the other arguments: .vert/.vs, .geom/.gs and .frag/.fs
+//
+// It exits with status 1 if any errors were found
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+type defineFlags map[string]string
+
+func (d defineFlags) String() string {
+	return fmt.Sprint(map[string]string(d))
+}
+
+func (d defineFlags) Set(value string) error {
+	name, define, _ := strings.Cut(value, "=")
+	if name == "" {
+		return errors.New("empty define name")
+	}
+	d[name] = define
+	return nil
+}
+
+var stageExtensions = map[string]glsl.Stage{
+	".vert": glsl.Vertex,
+	".vs":   glsl.Vertex,
+	".geom": glsl.Geometry,
+	".gs":   glsl.Geometry,
+	".frag": glsl.Fragment,
+	".fs":   glsl.Fragment,
+}
+
+// the stage names glslangValidator uses with -S
+var glslangStages = map[glsl.Stage]string{
+	glsl.Vertex:   "vert",
+	glsl.Geometry: "geom",
+	glsl.Fragment: "frag",
+}
+
+type stageFile struct {
+	stage glsl.Stage
+	path  string
+}
+
+func main() {
+	vert := flag.String("vert", "", "the vertex shader")
+	geom := flag.String("geom", "", "the geometry shader")
+	frag := flag.String("frag", "", "the fragment shader")
+	defines := defineFlags{}
+	flag.Var(defines, "D", "a define to inject after #version as NAME or NAME=VALUE, can be repeated")
+	glslang := flag.String("glslang", "auto", `the glslangValidator to compile with, "auto" to use it if it's on the PATH or "off"`)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: glslcheck [flags] [shader files...]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var files []stageFile
+	for _, file := range []stageFile{{glsl.Vertex, *vert}, {glsl.Geometry, *geom}, {glsl.Fragment, *frag}} {
+		if file.path != "" {
+			files = append(files, file)
+		}
+	}
+	for _, path := range flag.Args() {
+		stage, ok := stageExtensions[strings.ToLower(filepath.Ext(path))]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "glslcheck: can't tell the stage of %s from its extension, use -vert, -geom or -frag\n", path)
+			os.Exit(2)
+		}
+		files = append(files, stageFile{stage, path})
+	}
+	if len(files) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	validator, err := findGlslang(*glslang)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "glslcheck: %v\n", err)
+		os.Exit(2)
+	}
+
+	if !check(files, defines, validator) {
+		os.Exit(1)
+	}
+}
+
+func findGlslang(setting string) (string, error) {
+	switch setting {
+	case "off":
+		return "", nil
+	case "auto":
+		path, err := exec.LookPath("glslangValidator")
+		if err != nil {
+			return "", nil
+		}
+		return path, nil
+	}
+	return exec.LookPath(setting)
+}
+
+// prints every error and reports whether there weren't any
+func check(files []stageFile, defines map[string]string, validator string) bool {
+	ok := true
+	report := func(err error) {
+		fmt.Fprintln(os.Stderr, err)
+		ok = false
+	}
+
+	var stages []glsl.StageSource
+	for _, file := range files {
+		source, err := glsl.Preprocess(file.path)
+		if err != nil {
+			report(err)
+			continue
+		}
+		source.Source, err = glsl.InjectDefines(source.Source, defines)
+		if err != nil {
+			report(err)
+			continue
+		}
+		stages = append(stages, glsl.StageSource{Stage: file.stage, Source: source})
+	}
+
+	for _, err := range glsl.CheckProgram(stages...) {
+		report(err)
+	}
+
+	if validator != "" {
+		for _, stage := range stages {
+			if err := compile(validator, stage); err != nil {
+				report(err)
+			}
+		}
+	}
+	return ok
+}
+
+// runs a stage through glslangValidator with the
+// log pointing at the files the errors are in
+func compile(validator string, stage glsl.StageSource) error {
+	cmd := exec.Command(validator, "--stdin", "-S", glslangStages[stage.Stage])
+	cmd.Stdin = strings.NewReader(stage.Source.Source)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return fmt.Errorf("%s", strings.TrimSpace(stage.Source.RemapLog(out.String())))
+	}
+	return err
+}
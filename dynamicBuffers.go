@@ -0,0 +1,230 @@
+package gogl
+
+import (
+	"errors"
+	"fmt"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// a buffer object that knows its size and usage so its data can be
+// updated, orphaned or grown after it's created. Growing keeps the
+// same buffer id so vertex arrays pointing at it stay valid
+type Buffer struct {
+	id     BufferID
+	target uint32
+	usage  uint32
+	size   int
+}
+
+// allocates size bytes for a new buffer, usage is e.g. gl.DYNAMIC_DRAW
+func NewBuffer(target uint32, size int, usage uint32) *Buffer {
+	b := Buffer{
+		id:     GenBindBuffer(target),
+		target: target,
+		usage:  usage,
+		size:   size,
+	}
+	ogl.BufferData(target, size, nil, usage)
+	return &b
+}
+
+// a new buffer holding data
+func NewBufferWithData[T any](target uint32, data []T, usage uint32) *Buffer {
+	b := Buffer{
+		id:     GenBindBuffer(target),
+		target: target,
+		usage:  usage,
+		size:   byteSize(data),
+	}
+	BufferData(target, data, usage)
+	return &b
+}
+
+// wraps a buffer made elsewhere, like by a BufferLoader,
+// reading its size and usage back from gl
+func ExistingBuffer(target uint32, id BufferID) *Buffer {
+	ogl.BindBuffer(target, uint32(id))
+
+	var size, usage int32
+	ogl.GetBufferParameteriv(target, gl.BUFFER_SIZE, &size)
+	ogl.GetBufferParameteriv(target, gl.BUFFER_USAGE, &usage)
+
+	return &Buffer{
+		id:     id,
+		target: target,
+		usage:  uint32(usage),
+		size:   int(size),
+	}
+}
+
+func byteSize[T any](data []T) int {
+	var v T
+	return len(data) * int(unsafe.Sizeof(v))
+}
+
+func (b *Buffer) ID() BufferID {
+	return b.id
+}
+
+func (b *Buffer) Target() uint32 {
+	return b.target
+}
+
+func (b *Buffer) Usage() uint32 {
+	return b.usage
+}
+
+// the allocated size in bytes
+func (b *Buffer) Size() int {
+	return b.size
+}
+
+func (b *Buffer) Delete() {
+	b.id.Delete()
+	b.id = 0
+	b.size = 0
+}
+
+func (b *Buffer) Bind() {
+	ogl.BindBuffer(b.target, uint32(b.id))
+}
+
+// replaces the contents of the buffer with data starting at the beginning
+// if data doesn't fit the buffer is reallocated growing the same way as Grow
+func SetBufferData[T any](b *Buffer, data []T) {
+	size := byteSize(data)
+	b.Bind()
+	if size > b.size {
+		//the old contents are being replaced so there's nothing to copy
+		b.size = b.grownSize(size)
+		ogl.BufferData(b.target, b.size, nil, b.usage)
+	}
+	if size > 0 {
+		ogl.BufferSubData(b.target, 0, size, gl.Ptr(data))
+	}
+}
+
+// overwrites part of the buffer with data starting offset bytes in
+func UpdateBuffer[T any](b *Buffer, offset int, data []T) error {
+	size := byteSize(data)
+	if offset < 0 || offset+size > b.size {
+		return fmt.Errorf("writing %d bytes at %d is outside the buffer of %d bytes", size, offset, b.size)
+	}
+	if size == 0 {
+		return nil
+	}
+
+	b.Bind()
+	ogl.BufferSubData(b.target, offset, size, gl.Ptr(data))
+	return nil
+}
+
+// gives the buffer new storage of the same size so writing to it doesn't
+// wait for draws still using the old data, the contents become undefined
+func (b *Buffer) Orphan() {
+	b.Bind()
+	ogl.BufferData(b.target, b.size, nil, b.usage)
+}
+
+// the size to reallocate to for at least size bytes, at least
+// doubling so repeatedly growing a buffer stays cheap
+func (b *Buffer) grownSize(size int) int {
+	return max(size, b.size*2)
+}
+
+// reallocates the buffer to hold at least size bytes keeping its contents
+// it at least doubles the size so repeatedly growing a buffer stays cheap
+func (b *Buffer) Grow(size int) error {
+	if b.id == 0 {
+		return errors.New("can't grow a deleted buffer")
+	}
+	if size <= b.size {
+		return nil
+	}
+	newSize := b.grownSize(size)
+
+	if b.size == 0 {
+		b.Bind()
+		ogl.BufferData(b.target, newSize, nil, b.usage)
+		b.size = newSize
+		return nil
+	}
+
+	//the data is copied out and back so the buffer keeps its id
+	temp := GenBindBuffer(gl.COPY_WRITE_BUFFER)
+	ogl.BufferData(gl.COPY_WRITE_BUFFER, b.size, nil, gl.STREAM_COPY)
+	ogl.BindBuffer(gl.COPY_READ_BUFFER, uint32(b.id))
+	ogl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, b.size)
+
+	ogl.BufferData(gl.COPY_READ_BUFFER, newSize, nil, b.usage)
+	ogl.BindBuffer(gl.COPY_READ_BUFFER, uint32(temp))
+	ogl.BindBuffer(gl.COPY_WRITE_BUFFER, uint32(b.id))
+	ogl.CopyBufferSubData(gl.COPY_READ_BUFFER, gl.COPY_WRITE_BUFFER, 0, 0, b.size)
+
+	temp.Delete()
+	b.size = newSize
+	return nil
+}
+
+// a buffer for data written every frame, like particles or debug lines
+// each write goes after the last one so the gpu can still be drawing
+// the earlier data, once the end is reached the buffer is orphaned
+// and writing starts again from the beginning
+type StreamBuffer struct {
+	*Buffer
+	offset int
+}
+
+// where a write to a StreamBuffer ended up
+type StreamRange struct {
+	Offset int   // in bytes
+	First  int32 // the index of the first element e.g. for gl.DrawArrays
+	Count  int32
+}
+
+func NewStreamBuffer(target uint32, size int) *StreamBuffer {
+	return &StreamBuffer{Buffer: NewBuffer(target, size, gl.STREAM_DRAW)}
+}
+
+// copies data into the next free part of the buffer, the offset is
+// aligned to the size of T so the elements can be drawn with First
+func WriteStream[T any](s *StreamBuffer, data []T) (StreamRange, error) {
+	var v T
+	elemSize := int(unsafe.Sizeof(v))
+	size := byteSize(data)
+	if size == 0 {
+		return StreamRange{}, nil
+	}
+
+	offset := roundUp(s.offset, elemSize)
+	access := uint32(gl.MAP_WRITE_BIT | gl.MAP_INVALIDATE_RANGE_BIT | gl.MAP_UNSYNCHRONIZED_BIT)
+	if offset+size > s.size {
+		if size > s.size {
+			//nothing needs keeping so the growth doesn't have to copy
+			s.size = s.grownSize(size)
+		}
+		s.Orphan()
+		offset = 0
+		access = gl.MAP_WRITE_BIT | gl.MAP_INVALIDATE_BUFFER_BIT
+	}
+
+	s.Bind()
+	ptr := ogl.MapBufferRange(s.target, offset, size, access)
+	if ptr == nil {
+		return StreamRange{}, errors.New("failed to map the stream buffer")
+	}
+	copy(unsafe.Slice((*byte)(ptr), size), unsafe.Slice((*byte)(unsafe.Pointer(&data[0])), size))
+	if !ogl.UnmapBuffer(s.target) {
+		//the data store was lost e.g. by a display mode change
+		return StreamRange{}, errors.New("the stream buffer's data was corrupted while it was mapped")
+	}
+
+	s.offset = offset + size
+	return StreamRange{
+		Offset: offset,
+		First:  int32(offset / elemSize),
+		Count:  int32(len(data)),
+	}, nil
+}
@@ -0,0 +1,77 @@
+package gogl
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestSetBufferDataGrowth(t *testing.T) {
+	tests := []struct {
+		name     string
+		initial  int
+		data     []byte
+		wantSize int
+	}{
+		{"fits", 16, bytes.Repeat([]byte{1}, 8), 16},
+		{"exactly fits", 16, bytes.Repeat([]byte{1}, 16), 16},
+		{"doubles", 16, bytes.Repeat([]byte{1}, 20), 32},
+		{"bigger than double", 16, bytes.Repeat([]byte{1}, 40), 40},
+		{"from empty", 0, bytes.Repeat([]byte{1}, 4), 4},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake, restore := UseRecordingGL()
+			defer restore()
+
+			b := NewBuffer(gl.ARRAY_BUFFER, test.initial, gl.DYNAMIC_DRAW)
+			defer b.Delete()
+			SetBufferData(b, test.data)
+
+			if b.Size() != test.wantSize {
+				t.Errorf("size = %d, want %d", b.Size(), test.wantSize)
+			}
+			stored := fake.Buffer(b.ID())
+			if len(stored.Data) != test.wantSize || !bytes.HasPrefix(stored.Data, test.data) {
+				t.Errorf("buffer holds %v", stored.Data)
+			}
+			if len(fake.Errors) > 0 {
+				t.Errorf("gl errors: %v", fake.Errors)
+			}
+		})
+	}
+}
+
+func TestBufferGrow(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	defer restore()
+
+	b := NewBufferWithData(gl.ARRAY_BUFFER, []byte{1, 2, 3, 4}, gl.DYNAMIC_DRAW)
+	id := b.ID()
+
+	if err := b.Grow(2); err != nil || b.Size() != 4 {
+		t.Errorf("growing to a smaller size = %v with size %d", err, b.Size())
+	}
+	if err := b.Grow(5); err != nil {
+		t.Fatal(err)
+	}
+	if b.Size() != 8 || b.ID() != id {
+		t.Errorf("grew to %d bytes with id %d, want 8 with id %d", b.Size(), b.ID(), id)
+	}
+	if data := fake.Buffer(id).Data; !bytes.Equal(data[:4], []byte{1, 2, 3, 4}) || len(data) != 8 {
+		t.Errorf("buffer holds %v after growing", data)
+	}
+	if live := fake.Live(ResourceBuffer); len(live) != 1 {
+		t.Errorf("the temporary buffer wasn't deleted, live buffers %v", live)
+	}
+
+	b.Delete()
+	if err := b.Grow(16); err == nil {
+		t.Error("growing a deleted buffer should be an error")
+	}
+	if len(fake.Errors) > 0 {
+		t.Errorf("gl errors: %v", fake.Errors)
+	}
+}
@@ -0,0 +1,102 @@
+//go:build linux
+
+package gogl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+// saving through a temporary file replaces the watched file so the
+// directories are watched instead of the files themselves
+const inotifyMask = syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_CREATE |
+	syscall.IN_DELETE | syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO
+
+type inotifyNotifier struct {
+	fd   int
+	file *os.File
+	// called with the absolute path of every changed file
+	changed func(path string)
+
+	mu      sync.Mutex
+	dirs    map[int32]string
+	watches map[string]int32
+}
+
+func newFileNotifier(changed func(path string)) (fileNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+
+	n := inotifyNotifier{
+		fd: fd,
+		//a non blocking fd goes through the runtime poller so closing the file ends the read
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		changed: changed,
+		dirs:    make(map[int32]string),
+		watches: make(map[string]int32),
+	}
+	return &n, nil
+}
+
+func (n *inotifyNotifier) watchDir(dir string) error {
+	wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+
+	n.mu.Lock()
+	n.dirs[int32(wd)] = dir
+	n.watches[dir] = int32(wd)
+	n.mu.Unlock()
+	return nil
+}
+
+func (n *inotifyNotifier) unwatchDir(dir string) error {
+	n.mu.Lock()
+	wd, ok := n.watches[dir]
+	delete(n.watches, dir)
+	delete(n.dirs, wd)
+	n.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if _, err := syscall.InotifyRmWatch(n.fd, uint32(wd)); err != nil {
+		return &os.PathError{Op: "inotify_rm_watch", Path: dir, Err: err}
+	}
+	return nil
+}
+
+func (n *inotifyNotifier) readEvents() {
+	buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= count; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+int(event.Len)]), "\x00")
+			offset = nameStart + int(event.Len)
+
+			n.mu.Lock()
+			dir, ok := n.dirs[event.Wd]
+			n.mu.Unlock()
+			if ok && name != "" {
+				n.changed(filepath.Join(dir, name))
+			}
+		}
+	}
+}
+
+func (n *inotifyNotifier) close() error {
+	return n.file.Close()
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package gogl
+
+import "errors"
+
+// only linux has a notifier, everywhere else the watcher polls
+func newFileNotifier(changed func(path string)) (fileNotifier, error) {
+	return nil, errors.New("file notifications aren't supported on this platform")
+}
@@ -0,0 +1,28 @@
+package gogl
+
+import (
+	"io/fs"
+	"time"
+)
+
+func getModTime(fsys fs.FS, name string) (time.Time, error) {
+	file, err := fs.Stat(fsys, name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return file.ModTime(), nil
+}
+
+// files in an embed.FS never change and have no mod time so hot
+// reloading is only enabled when every file reports a mod time like
+// with os.DirFS. Checking the mod times rather than the type of fsys
+// also catches a *embed.FS or one wrapped by fs.Sub
+func canHotReload(fsys fs.FS, names ...string) bool {
+	for _, name := range names {
+		modTime, err := getModTime(fsys, name)
+		if err != nil || modTime.IsZero() {
+			return false
+		}
+	}
+	return len(names) > 0
+}
@@ -0,0 +1,51 @@
+package gogl
+
+import (
+	"embed"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestCanHotReload(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	withTimes := fstest.MapFS{
+		"shaders/a.vert": {Data: []byte("a"), ModTime: modified},
+		"shaders/a.frag": {Data: []byte("b"), ModTime: modified},
+	}
+	withoutTimes := fstest.MapFS{
+		"shaders/a.vert": {Data: []byte("a")},
+		"shaders/a.frag": {Data: []byte("b")},
+	}
+	mixed := fstest.MapFS{
+		"shaders/a.vert": {Data: []byte("a"), ModTime: modified},
+		"shaders/a.frag": {Data: []byte("b")},
+	}
+	subWithTimes, _ := fs.Sub(withTimes, "shaders")
+	subWithoutTimes, _ := fs.Sub(withoutTimes, "shaders")
+
+	tests := []struct {
+		name  string
+		fsys  fs.FS
+		files []string
+		want  bool
+	}{
+		{"mod times", withTimes, []string{"shaders/a.vert", "shaders/a.frag"}, true},
+		{"no mod times", withoutTimes, []string{"shaders/a.vert", "shaders/a.frag"}, false},
+		{"one file without a mod time", mixed, []string{"shaders/a.vert", "shaders/a.frag"}, false},
+		{"sub with mod times", subWithTimes, []string{"a.vert", "a.frag"}, true},
+		{"sub without mod times", subWithoutTimes, []string{"a.vert", "a.frag"}, false},
+		{"embed pointer", &embed.FS{}, []string{"a.vert"}, false},
+		{"missing file", withTimes, []string{"shaders/b.vert"}, false},
+		{"no files", withTimes, nil, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := canHotReload(test.fsys, test.files...); got != test.want {
+				t.Errorf("canHotReload = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
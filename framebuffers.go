@@ -0,0 +1,111 @@
+package gogl
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// an offscreen render target with an rgba color buffer and a depth
+// and stencil buffer, drawing to it works without a visible window
+type Framebuffer struct {
+	id     uint32
+	color  uint32
+	depth  uint32
+	width  int32
+	height int32
+}
+
+// a complete framebuffer of width by height pixels, it's left bound
+func NewFramebuffer(width, height int32) (*Framebuffer, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid framebuffer size %dx%d", width, height)
+	}
+
+	f := Framebuffer{width: width, height: height}
+	ogl.GenFramebuffers(1, &f.id)
+	ogl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	trackResource(ResourceFramebuffer, f.id)
+
+	f.color = newRenderbuffer(gl.RGBA8, width, height)
+	ogl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.RENDERBUFFER, f.color)
+	f.depth = newRenderbuffer(gl.DEPTH24_STENCIL8, width, height)
+	ogl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, f.depth)
+
+	if status := ogl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		f.Delete()
+		return nil, fmt.Errorf("framebuffer is incomplete, status 0x%X", status)
+	}
+	ogl.Viewport(0, 0, width, height)
+	return &f, nil
+}
+
+func newRenderbuffer(format uint32, width, height int32) uint32 {
+	var id uint32
+	ogl.GenRenderbuffers(1, &id)
+	ogl.BindRenderbuffer(gl.RENDERBUFFER, id)
+	trackResource(ResourceRenderbuffer, id)
+	ogl.RenderbufferStorage(gl.RENDERBUFFER, format, width, height)
+	return id
+}
+
+func (f *Framebuffer) ID() uint32 {
+	return f.id
+}
+
+func (f *Framebuffer) Size() (width, height int32) {
+	return f.width, f.height
+}
+
+// draws to the framebuffer from now on and sets the viewport to cover it
+func (f *Framebuffer) Bind() {
+	ogl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	ogl.Viewport(0, 0, f.width, f.height)
+}
+
+// goes back to drawing to the window
+func BindDefaultFramebuffer() {
+	ogl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+}
+
+// reads back everything drawn to the framebuffer
+func (f *Framebuffer) ReadImage() *image.RGBA {
+	ogl.BindFramebuffer(gl.FRAMEBUFFER, f.id)
+	return ReadPixels(0, 0, f.width, f.height)
+}
+
+func (f *Framebuffer) Delete() {
+	if f.id == 0 {
+		return
+	}
+	ogl.DeleteRenderbuffers(1, &f.color)
+	untrackResource(ResourceRenderbuffer, f.color)
+	ogl.DeleteRenderbuffers(1, &f.depth)
+	untrackResource(ResourceRenderbuffer, f.depth)
+	ogl.DeleteFramebuffers(1, &f.id)
+	untrackResource(ResourceFramebuffer, f.id)
+	f.id, f.color, f.depth = 0, 0, 0
+}
+
+// reads a rectangle of the bound framebuffer, x and y are from the bottom
+// left like in gl but the image is flipped so its first row is the top
+func ReadPixels(x, y, width, height int32) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, int(width), int(height)))
+	if width <= 0 || height <= 0 {
+		return img
+	}
+	//rgba rows are always a multiple of 4 bytes so the default pack alignment is fine
+	ogl.ReadPixels(x, y, width, height, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(img.Pix))
+
+	//gl rows go bottom to top
+	row := make([]byte, img.Stride)
+	for top, bottom := 0, int(height)-1; top < bottom; top, bottom = top+1, bottom-1 {
+		topRow := img.Pix[top*img.Stride : (top+1)*img.Stride]
+		bottomRow := img.Pix[bottom*img.Stride : (bottom+1)*img.Stride]
+		copy(row, topRow)
+		copy(topRow, bottomRow)
+		copy(bottomRow, row)
+	}
+	return img
+}
@@ -0,0 +1,74 @@
+package gogl
+
+import (
+	"image"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestNewFramebuffer(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	defer restore()
+
+	f, err := NewFramebuffer(64, 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fake.BoundFramebuffer() != f.ID() || fake.CurrentViewport() != [4]int32{0, 0, 64, 32} {
+		t.Errorf("framebuffer %d and viewport %v are in use, want %d covering 64x32",
+			fake.BoundFramebuffer(), fake.CurrentViewport(), f.ID())
+	}
+
+	attachments := fake.Framebuffer(f.ID()).Attachments
+	formats := map[uint32]uint32{
+		gl.COLOR_ATTACHMENT0:        gl.RGBA8,
+		gl.DEPTH_STENCIL_ATTACHMENT: gl.DEPTH24_STENCIL8,
+	}
+	for attachment, format := range formats {
+		rb := fake.Renderbuffer(attachments[attachment])
+		if rb == nil || rb.InternalFormat != format || rb.Width != 64 || rb.Height != 32 {
+			t.Errorf("attachment 0x%X is %+v, want 64x32 of 0x%X", attachment, rb, format)
+		}
+	}
+
+	BindDefaultFramebuffer()
+	fake.Reset()
+	img := f.ReadImage()
+	if img.Bounds() != image.Rect(0, 0, 64, 32) {
+		t.Errorf("read an image of %v", img.Bounds())
+	}
+	reads := fake.CallsTo("ReadPixels")
+	if len(reads) != 1 || reads[0].Args[2] != int32(64) || reads[0].Args[3] != int32(32) ||
+		reads[0].Args[4] != uint32(gl.RGBA) || reads[0].Args[5] != uint32(gl.UNSIGNED_BYTE) {
+		t.Errorf("read pixels with %v, want 64x32 of rgba bytes", reads)
+	}
+	if fake.BoundFramebuffer() != f.ID() {
+		t.Error("ReadImage didn't read from the framebuffer")
+	}
+
+	f.Delete()
+	f.Delete() //deleting twice does nothing
+	for _, kind := range []string{ResourceFramebuffer, ResourceRenderbuffer} {
+		if live := fake.Live(kind); len(live) != 0 {
+			t.Errorf("%ss %v are left after Delete", kind, live)
+		}
+	}
+	if len(fake.Errors) != 0 {
+		t.Errorf("gl errors %v", fake.Errors)
+	}
+}
+
+func TestNewFramebufferInvalidSize(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	defer restore()
+
+	for _, size := range [][2]int32{{0, 32}, {64, -1}} {
+		if _, err := NewFramebuffer(size[0], size[1]); err == nil {
+			t.Errorf("a %dx%d framebuffer was made", size[0], size[1])
+		}
+	}
+	if len(fake.Calls) != 0 {
+		t.Errorf("an invalid size still called %v", fake.Calls)
+	}
+}
@@ -0,0 +1,494 @@
+package gogl
+
+import (
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// every gl function the package calls, so the calls can go to a
+// fake like RecordingGL in tests that have no gpu. The methods
+// have the same signatures as the functions in go-gl
+type glAPI interface {
+	// buffers
+	GenBuffers(n int32, buffers *uint32)
+	DeleteBuffers(n int32, buffers *uint32)
+	BindBuffer(target uint32, buffer uint32)
+	BindBufferBase(target uint32, index uint32, buffer uint32)
+	BufferData(target uint32, size int, data unsafe.Pointer, usage uint32)
+	BufferSubData(target uint32, offset int, size int, data unsafe.Pointer)
+	CopyBufferSubData(readTarget uint32, writeTarget uint32, readOffset int, writeOffset int, size int)
+	GetBufferParameteriv(target uint32, pname uint32, params *int32)
+	MapBufferRange(target uint32, offset int, length int, access uint32) unsafe.Pointer
+	UnmapBuffer(target uint32) bool
+
+	// vertex arrays
+	GenVertexArrays(n int32, arrays *uint32)
+	DeleteVertexArrays(n int32, arrays *uint32)
+	BindVertexArray(array uint32)
+	VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer)
+	VertexAttribPointerWithOffset(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uintptr)
+	VertexAttribIPointerWithOffset(index uint32, size int32, xtype uint32, stride int32, offset uintptr)
+	VertexAttribDivisor(index uint32, divisor uint32)
+	EnableVertexAttribArray(index uint32)
+	DisableVertexAttribArray(index uint32)
+
+	// drawing
+	DrawArrays(mode uint32, first int32, count int32)
+	DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32)
+	DrawElementsWithOffset(mode uint32, count int32, xtype uint32, indices uintptr)
+	DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32)
+
+	// textures
+	GenTextures(n int32, textures *uint32)
+	DeleteTextures(n int32, textures *uint32)
+	BindTexture(target uint32, texture uint32)
+	TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+	TexParameteri(target uint32, pname uint32, param int32)
+	GenerateMipmap(target uint32)
+
+	// shaders and programs
+	CreateShader(xtype uint32) uint32
+	DeleteShader(shader uint32)
+	ShaderSource(shader uint32, count int32, xstring **uint8, length *int32)
+	CompileShader(shader uint32)
+	GetShaderiv(shader uint32, pname uint32, params *int32)
+	GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8)
+	CreateProgram() uint32
+	DeleteProgram(program uint32)
+	AttachShader(program uint32, shader uint32)
+	LinkProgram(program uint32)
+	UseProgram(program uint32)
+	GetProgramiv(program uint32, pname uint32, params *int32)
+	GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8)
+	ProgramParameteri(program uint32, pname uint32, value int32)
+	GetProgramBinary(program uint32, bufSize int32, length *int32, binaryFormat *uint32, binary unsafe.Pointer)
+	ProgramBinary(program uint32, binaryFormat uint32, binary unsafe.Pointer, length int32)
+	GetActiveAttrib(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8)
+	GetAttribLocation(program uint32, name *uint8) int32
+	GetActiveUniform(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8)
+	GetUniformLocation(program uint32, name *uint8) int32
+	GetUniformBlockIndex(program uint32, uniformBlockName *uint8) uint32
+	UniformBlockBinding(program uint32, uniformBlockIndex uint32, uniformBlockBinding uint32)
+
+	// uniforms
+	Uniform1f(location int32, v0 float32)
+	Uniform1fv(location int32, count int32, value *float32)
+	Uniform1i(location int32, v0 int32)
+	Uniform1iv(location int32, count int32, value *int32)
+	Uniform1ui(location int32, v0 uint32)
+	Uniform1uiv(location int32, count int32, value *uint32)
+	Uniform2f(location int32, v0 float32, v1 float32)
+	Uniform2fv(location int32, count int32, value *float32)
+	Uniform2i(location int32, v0 int32, v1 int32)
+	Uniform2iv(location int32, count int32, value *int32)
+	Uniform2ui(location int32, v0 uint32, v1 uint32)
+	Uniform2uiv(location int32, count int32, value *uint32)
+	Uniform3fv(location int32, count int32, value *float32)
+	Uniform3i(location int32, v0 int32, v1 int32, v2 int32)
+	Uniform3iv(location int32, count int32, value *int32)
+	Uniform3ui(location int32, v0 uint32, v1 uint32, v2 uint32)
+	Uniform3uiv(location int32, count int32, value *uint32)
+	Uniform4f(location int32, v0 float32, v1 float32, v2 float32, v3 float32)
+	Uniform4fv(location int32, count int32, value *float32)
+	Uniform4i(location int32, v0 int32, v1 int32, v2 int32, v3 int32)
+	Uniform4iv(location int32, count int32, value *int32)
+	Uniform4ui(location int32, v0 uint32, v1 uint32, v2 uint32, v3 uint32)
+	Uniform4uiv(location int32, count int32, value *uint32)
+	UniformMatrix2fv(location int32, count int32, transpose bool, value *float32)
+	UniformMatrix3fv(location int32, count int32, transpose bool, value *float32)
+	UniformMatrix4fv(location int32, count int32, transpose bool, value *float32)
+
+	// framebuffers
+	GenFramebuffers(n int32, framebuffers *uint32)
+	DeleteFramebuffers(n int32, framebuffers *uint32)
+	BindFramebuffer(target uint32, framebuffer uint32)
+	FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32)
+	CheckFramebufferStatus(target uint32) uint32
+	GenRenderbuffers(n int32, renderbuffers *uint32)
+	DeleteRenderbuffers(n int32, renderbuffers *uint32)
+	BindRenderbuffer(target uint32, renderbuffer uint32)
+	RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32)
+	ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer)
+
+	// state
+	Enable(cap uint32)
+	Viewport(x int32, y int32, width int32, height int32)
+	GetIntegerv(pname uint32, data *int32)
+	GetString(name uint32) *uint8
+	GetStringi(name uint32, index uint32) *uint8
+}
+
+// the gl implementation used by the whole package
+var ogl glAPI = goGL{}
+
+// forwards every call to go-gl and so the real driver
+type goGL struct{}
+
+func (goGL) GenBuffers(n int32, buffers *uint32) {
+	gl.GenBuffers(n, buffers)
+}
+
+func (goGL) DeleteBuffers(n int32, buffers *uint32) {
+	gl.DeleteBuffers(n, buffers)
+}
+
+func (goGL) BindBuffer(target uint32, buffer uint32) {
+	gl.BindBuffer(target, buffer)
+}
+
+func (goGL) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	gl.BindBufferBase(target, index, buffer)
+}
+
+func (goGL) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	gl.BufferData(target, size, data, usage)
+}
+
+func (goGL) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	gl.BufferSubData(target, offset, size, data)
+}
+
+func (goGL) CopyBufferSubData(readTarget uint32, writeTarget uint32, readOffset int, writeOffset int, size int) {
+	gl.CopyBufferSubData(readTarget, writeTarget, readOffset, writeOffset, size)
+}
+
+func (goGL) GetBufferParameteriv(target uint32, pname uint32, params *int32) {
+	gl.GetBufferParameteriv(target, pname, params)
+}
+
+func (goGL) MapBufferRange(target uint32, offset int, length int, access uint32) unsafe.Pointer {
+	return gl.MapBufferRange(target, offset, length, access)
+}
+
+func (goGL) UnmapBuffer(target uint32) bool {
+	return gl.UnmapBuffer(target)
+}
+
+func (goGL) GenVertexArrays(n int32, arrays *uint32) {
+	gl.GenVertexArrays(n, arrays)
+}
+
+func (goGL) DeleteVertexArrays(n int32, arrays *uint32) {
+	gl.DeleteVertexArrays(n, arrays)
+}
+
+func (goGL) BindVertexArray(array uint32) {
+	gl.BindVertexArray(array)
+}
+
+func (goGL) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	gl.VertexAttribPointer(index, size, xtype, normalized, stride, pointer)
+}
+
+func (goGL) VertexAttribPointerWithOffset(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uintptr) {
+	gl.VertexAttribPointerWithOffset(index, size, xtype, normalized, stride, offset)
+}
+
+func (goGL) VertexAttribIPointerWithOffset(index uint32, size int32, xtype uint32, stride int32, offset uintptr) {
+	gl.VertexAttribIPointerWithOffset(index, size, xtype, stride, offset)
+}
+
+func (goGL) VertexAttribDivisor(index uint32, divisor uint32) {
+	gl.VertexAttribDivisor(index, divisor)
+}
+
+func (goGL) EnableVertexAttribArray(index uint32) {
+	gl.EnableVertexAttribArray(index)
+}
+
+func (goGL) DisableVertexAttribArray(index uint32) {
+	gl.DisableVertexAttribArray(index)
+}
+
+func (goGL) DrawArrays(mode uint32, first int32, count int32) {
+	gl.DrawArrays(mode, first, count)
+}
+
+func (goGL) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+	gl.DrawArraysInstanced(mode, first, count, instancecount)
+}
+
+func (goGL) DrawElementsWithOffset(mode uint32, count int32, xtype uint32, indices uintptr) {
+	gl.DrawElementsWithOffset(mode, count, xtype, indices)
+}
+
+func (goGL) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	gl.DrawElementsInstanced(mode, count, xtype, indices, instancecount)
+}
+
+func (goGL) GenTextures(n int32, textures *uint32) {
+	gl.GenTextures(n, textures)
+}
+
+func (goGL) DeleteTextures(n int32, textures *uint32) {
+	gl.DeleteTextures(n, textures)
+}
+
+func (goGL) BindTexture(target uint32, texture uint32) {
+	gl.BindTexture(target, texture)
+}
+
+func (goGL) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.TexImage2D(target, level, internalformat, width, height, border, format, xtype, pixels)
+}
+
+func (goGL) TexParameteri(target uint32, pname uint32, param int32) {
+	gl.TexParameteri(target, pname, param)
+}
+
+func (goGL) GenerateMipmap(target uint32) {
+	gl.GenerateMipmap(target)
+}
+
+func (goGL) CreateShader(xtype uint32) uint32 {
+	return gl.CreateShader(xtype)
+}
+
+func (goGL) DeleteShader(shader uint32) {
+	gl.DeleteShader(shader)
+}
+
+func (goGL) ShaderSource(shader uint32, count int32, xstring **uint8, length *int32) {
+	gl.ShaderSource(shader, count, xstring, length)
+}
+
+func (goGL) CompileShader(shader uint32) {
+	gl.CompileShader(shader)
+}
+
+func (goGL) GetShaderiv(shader uint32, pname uint32, params *int32) {
+	gl.GetShaderiv(shader, pname, params)
+}
+
+func (goGL) GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8) {
+	gl.GetShaderInfoLog(shader, bufSize, length, infoLog)
+}
+
+func (goGL) CreateProgram() uint32 {
+	return gl.CreateProgram()
+}
+
+func (goGL) DeleteProgram(program uint32) {
+	gl.DeleteProgram(program)
+}
+
+func (goGL) AttachShader(program uint32, shader uint32) {
+	gl.AttachShader(program, shader)
+}
+
+func (goGL) LinkProgram(program uint32) {
+	gl.LinkProgram(program)
+}
+
+func (goGL) UseProgram(program uint32) {
+	gl.UseProgram(program)
+}
+
+func (goGL) GetProgramiv(program uint32, pname uint32, params *int32) {
+	gl.GetProgramiv(program, pname, params)
+}
+
+func (goGL) GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8) {
+	gl.GetProgramInfoLog(program, bufSize, length, infoLog)
+}
+
+func (goGL) ProgramParameteri(program uint32, pname uint32, value int32) {
+	gl.ProgramParameteri(program, pname, value)
+}
+
+func (goGL) GetProgramBinary(program uint32, bufSize int32, length *int32, binaryFormat *uint32, binary unsafe.Pointer) {
+	gl.GetProgramBinary(program, bufSize, length, binaryFormat, binary)
+}
+
+func (goGL) ProgramBinary(program uint32, binaryFormat uint32, binary unsafe.Pointer, length int32) {
+	gl.ProgramBinary(program, binaryFormat, binary, length)
+}
+
+func (goGL) GetActiveAttrib(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	gl.GetActiveAttrib(program, index, bufSize, length, size, xtype, name)
+}
+
+func (goGL) GetAttribLocation(program uint32, name *uint8) int32 {
+	return gl.GetAttribLocation(program, name)
+}
+
+func (goGL) GetActiveUniform(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	gl.GetActiveUniform(program, index, bufSize, length, size, xtype, name)
+}
+
+func (goGL) GetUniformLocation(program uint32, name *uint8) int32 {
+	return gl.GetUniformLocation(program, name)
+}
+
+func (goGL) GetUniformBlockIndex(program uint32, uniformBlockName *uint8) uint32 {
+	return gl.GetUniformBlockIndex(program, uniformBlockName)
+}
+
+func (goGL) UniformBlockBinding(program uint32, uniformBlockIndex uint32, uniformBlockBinding uint32) {
+	gl.UniformBlockBinding(program, uniformBlockIndex, uniformBlockBinding)
+}
+
+func (goGL) Uniform1f(location int32, v0 float32) {
+	gl.Uniform1f(location, v0)
+}
+
+func (goGL) Uniform1fv(location int32, count int32, value *float32) {
+	gl.Uniform1fv(location, count, value)
+}
+
+func (goGL) Uniform1i(location int32, v0 int32) {
+	gl.Uniform1i(location, v0)
+}
+
+func (goGL) Uniform1iv(location int32, count int32, value *int32) {
+	gl.Uniform1iv(location, count, value)
+}
+
+func (goGL) Uniform1ui(location int32, v0 uint32) {
+	gl.Uniform1ui(location, v0)
+}
+
+func (goGL) Uniform1uiv(location int32, count int32, value *uint32) {
+	gl.Uniform1uiv(location, count, value)
+}
+
+func (goGL) Uniform2f(location int32, v0 float32, v1 float32) {
+	gl.Uniform2f(location, v0, v1)
+}
+
+func (goGL) Uniform2fv(location int32, count int32, value *float32) {
+	gl.Uniform2fv(location, count, value)
+}
+
+func (goGL) Uniform2i(location int32, v0 int32, v1 int32) {
+	gl.Uniform2i(location, v0, v1)
+}
+
+func (goGL) Uniform2iv(location int32, count int32, value *int32) {
+	gl.Uniform2iv(location, count, value)
+}
+
+func (goGL) Uniform2ui(location int32, v0 uint32, v1 uint32) {
+	gl.Uniform2ui(location, v0, v1)
+}
+
+func (goGL) Uniform2uiv(location int32, count int32, value *uint32) {
+	gl.Uniform2uiv(location, count, value)
+}
+
+func (goGL) Uniform3fv(location int32, count int32, value *float32) {
+	gl.Uniform3fv(location, count, value)
+}
+
+func (goGL) Uniform3i(location int32, v0 int32, v1 int32, v2 int32) {
+	gl.Uniform3i(location, v0, v1, v2)
+}
+
+func (goGL) Uniform3iv(location int32, count int32, value *int32) {
+	gl.Uniform3iv(location, count, value)
+}
+
+func (goGL) Uniform3ui(location int32, v0 uint32, v1 uint32, v2 uint32) {
+	gl.Uniform3ui(location, v0, v1, v2)
+}
+
+func (goGL) Uniform3uiv(location int32, count int32, value *uint32) {
+	gl.Uniform3uiv(location, count, value)
+}
+
+func (goGL) Uniform4f(location int32, v0 float32, v1 float32, v2 float32, v3 float32) {
+	gl.Uniform4f(location, v0, v1, v2, v3)
+}
+
+func (goGL) Uniform4fv(location int32, count int32, value *float32) {
+	gl.Uniform4fv(location, count, value)
+}
+
+func (goGL) Uniform4i(location int32, v0 int32, v1 int32, v2 int32, v3 int32) {
+	gl.Uniform4i(location, v0, v1, v2, v3)
+}
+
+func (goGL) Uniform4iv(location int32, count int32, value *int32) {
+	gl.Uniform4iv(location, count, value)
+}
+
+func (goGL) Uniform4ui(location int32, v0 uint32, v1 uint32, v2 uint32, v3 uint32) {
+	gl.Uniform4ui(location, v0, v1, v2, v3)
+}
+
+func (goGL) Uniform4uiv(location int32, count int32, value *uint32) {
+	gl.Uniform4uiv(location, count, value)
+}
+
+func (goGL) UniformMatrix2fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix2fv(location, count, transpose, value)
+}
+
+func (goGL) UniformMatrix3fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix3fv(location, count, transpose, value)
+}
+
+func (goGL) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	gl.UniformMatrix4fv(location, count, transpose, value)
+}
+
+func (goGL) GenFramebuffers(n int32, framebuffers *uint32) {
+	gl.GenFramebuffers(n, framebuffers)
+}
+
+func (goGL) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	gl.DeleteFramebuffers(n, framebuffers)
+}
+
+func (goGL) BindFramebuffer(target uint32, framebuffer uint32) {
+	gl.BindFramebuffer(target, framebuffer)
+}
+
+func (goGL) FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	gl.FramebufferRenderbuffer(target, attachment, renderbuffertarget, renderbuffer)
+}
+
+func (goGL) CheckFramebufferStatus(target uint32) uint32 {
+	return gl.CheckFramebufferStatus(target)
+}
+
+func (goGL) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.GenRenderbuffers(n, renderbuffers)
+}
+
+func (goGL) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	gl.DeleteRenderbuffers(n, renderbuffers)
+}
+
+func (goGL) BindRenderbuffer(target uint32, renderbuffer uint32) {
+	gl.BindRenderbuffer(target, renderbuffer)
+}
+
+func (goGL) RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	gl.RenderbufferStorage(target, internalformat, width, height)
+}
+
+func (goGL) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	gl.ReadPixels(x, y, width, height, format, xtype, pixels)
+}
+
+func (goGL) Enable(cap uint32) {
+	gl.Enable(cap)
+}
+
+func (goGL) Viewport(x int32, y int32, width int32, height int32) {
+	gl.Viewport(x, y, width, height)
+}
+
+func (goGL) GetIntegerv(pname uint32, data *int32) {
+	gl.GetIntegerv(pname, data)
+}
+
+func (goGL) GetString(name uint32) *uint8 {
+	return gl.GetString(name)
+}
+
+func (goGL) GetStringi(name uint32, index uint32) *uint8 {
+	return gl.GetStringi(name, index)
+}
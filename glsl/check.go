@@ -0,0 +1,242 @@
+package glsl
+
+import (
+	"fmt"
+	"sort"
+)
+
+// a problem found in a shader, File and Line point at
+// the original file rather than the preprocessed source
+type Error struct {
+	File    string
+	Line    int
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("%s:%d: %s", e.File, e.Line, e.Message)
+}
+
+// a shader stage, the values are the gl enums
+// so e.g. gl.VERTEX_SHADER can be used as a Stage
+type Stage uint32
+
+const (
+	Vertex   Stage = 0x8B31
+	Geometry Stage = 0x8DD9
+	Fragment Stage = 0x8B30
+)
+
+func (s Stage) String() string {
+	switch s {
+	case Vertex:
+		return "vertex"
+	case Geometry:
+		return "geometry"
+	case Fragment:
+		return "fragment"
+	}
+	return fmt.Sprintf("stage(0x%X)", uint32(s))
+}
+
+// where the stage runs in the pipeline, unknown stages go last
+func (s Stage) order() int {
+	switch s {
+	case Vertex:
+		return 0
+	case Geometry:
+		return 1
+	case Fragment:
+		return 2
+	}
+	return 3
+}
+
+// the source of a shader and the stage it's used for. A source
+// without #line directives or Files is just the code as written
+type StageSource struct {
+	Stage  Stage
+	Source *Source
+}
+
+// the parts of a shader the checks look at
+type parsedShader struct {
+	source     *Source
+	tokens     []token
+	directives []directive
+	positions  []position
+}
+
+func parseShader(s *Source) *parsedShader {
+	tokens, directives := tokenize(s.Source)
+	return &parsedShader{
+		source:     s,
+		tokens:     tokens,
+		directives: directives,
+		positions:  linePositions(s.Source),
+	}
+}
+
+func (p *parsedShader) errorf(line int, format string, args ...any) error {
+	file, fileLine := p.source.position(p.positions[line])
+	return &Error{
+		File:    file,
+		Line:    fileLine,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// looks for the errors that can be found without a compiler: a missing or
+// misplaced #version, unknown or unbalanced preprocessor directives,
+// unbalanced brackets, a missing ; at the end of a block and a missing main
+//
+// it can't catch everything a driver would, run the shader through a real
+// compiler like glslangValidator for that
+func CheckSyntax(s *Source) []error {
+	return parseShader(s).checkSyntax()
+}
+
+func (p *parsedShader) checkSyntax() []error {
+	var errs []error
+	errs = append(errs, p.checkDirectives()...)
+	errs = append(errs, p.checkBrackets()...)
+	if !p.hasMain() {
+		errs = append(errs, p.errorf(0, "no main function"))
+	}
+	return errs
+}
+
+var knownDirectives = map[string]bool{
+	"": true, "define": true, "undef": true, "if": true, "ifdef": true, "ifndef": true,
+	"else": true, "elif": true, "endif": true, "error": true, "pragma": true,
+	"extension": true, "version": true, "line": true,
+}
+
+func (p *parsedShader) checkDirectives() []error {
+	var errs []error
+
+	version := -1
+	for i, d := range p.directives {
+		if d.name != "version" {
+			continue
+		}
+		if version >= 0 {
+			errs = append(errs, p.errorf(d.line, "#version is used more than once"))
+			continue
+		}
+		version = i
+		if i > 0 || (len(p.tokens) > 0 && p.tokens[0].line < d.line) {
+			errs = append(errs, p.errorf(d.line, "#version has to come before anything else"))
+		}
+	}
+	if version < 0 {
+		errs = append(errs, p.errorf(0, "no #version, shaders without one are treated as glsl 1.10"))
+	}
+
+	var conditionals []directive
+	for _, d := range p.directives {
+		if !knownDirectives[d.name] {
+			errs = append(errs, p.errorf(d.line, "unknown preprocessor directive #%s", d.name))
+		}
+
+		switch d.name {
+		case "if", "ifdef", "ifndef":
+			conditionals = append(conditionals, d)
+		case "else", "elif":
+			if len(conditionals) == 0 {
+				errs = append(errs, p.errorf(d.line, "#%s without #if", d.name))
+			}
+		case "endif":
+			if len(conditionals) == 0 {
+				errs = append(errs, p.errorf(d.line, "#endif without #if"))
+				continue
+			}
+			conditionals = conditionals[:len(conditionals)-1]
+		}
+	}
+	for _, d := range conditionals {
+		errs = append(errs, p.errorf(d.line, "#%s is never closed with #endif", d.name))
+	}
+	return errs
+}
+
+var closingBrackets = map[string]string{")": "(", "]": "[", "}": "{"}
+
+func (p *parsedShader) checkBrackets() []error {
+	var errs []error
+	//initializer lists like {1, 2} only exist from 4.20
+	initializerLists := sourceVersion(p.directives) >= 420
+
+	var open []token
+	for i, t := range p.tokens {
+		switch t.text {
+		case "(", "[", "{":
+			open = append(open, t)
+		case ")", "]", "}":
+			if len(open) == 0 || open[len(open)-1].text != closingBrackets[t.text] {
+				errs = append(errs, p.errorf(t.line, "unexpected %s", t.text))
+				continue
+			}
+			open = open[:len(open)-1]
+
+			if t.text == "}" && !initializerLists && i > 0 {
+				switch p.tokens[i-1].text {
+				case ";", "{", "}":
+				default:
+					errs = append(errs, p.errorf(p.tokens[i-1].line, "missing ; after %s", p.tokens[i-1].text))
+				}
+			}
+		}
+	}
+	for _, t := range open {
+		errs = append(errs, p.errorf(t.line, "%s is never closed", t.text))
+	}
+	return errs
+}
+
+func (p *parsedShader) hasMain() bool {
+	depth := 0
+	for i, t := range p.tokens {
+		switch t.text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+		case "main":
+			if depth == 0 && i > 0 && i+1 < len(p.tokens) && p.tokens[i-1].text == "void" && p.tokens[i+1].text == "(" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checks the syntax of every stage and that each stage
+// reads only what the stage before it writes
+func CheckProgram(stages ...StageSource) []error {
+	sorted := append([]StageSource(nil), stages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Stage.order() < sorted[j].Stage.order()
+	})
+
+	var errs []error
+	parsed := make([]*parsedShader, len(sorted))
+	for i, stage := range sorted {
+		if i > 0 && sorted[i-1].Stage == stage.Stage {
+			errs = append(errs, fmt.Errorf("the %s stage is used more than once", stage.Stage))
+			return errs
+		}
+		parsed[i] = parseShader(stage.Source)
+		errs = append(errs, parsed[i].checkSyntax()...)
+	}
+
+	for i := 1; i < len(sorted); i++ {
+		prev := parsed[i-1].parseInterface(sorted[i-1].Stage)
+		next := parsed[i].parseInterface(sorted[i].Stage)
+		errs = append(errs, CheckInterface(prev, next)...)
+	}
+	return errs
+}
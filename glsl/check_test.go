@@ -0,0 +1,34 @@
+package glsl
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckProgramStageOrder(t *testing.T) {
+	vert := &Source{Source: "#version 330 core\nout vec3 vColor;\nvoid main() { vColor = vec3(1); }\n"}
+	frag := &Source{Source: "#version 330 core\nin vec3 vNormal;\nout vec4 color;\nvoid main() { color = vec4(vNormal, 1); }\n"}
+
+	//the stages are checked in pipeline order whatever order they're given in
+	errs := CheckProgram(StageSource{Stage: Fragment, Source: frag}, StageSource{Stage: Vertex, Source: vert})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "fragment input vNormal isn't an output of the vertex shader") {
+		t.Errorf("errors = %v", errs)
+	}
+
+	errs = CheckProgram(StageSource{Stage: Vertex, Source: vert}, StageSource{Stage: Vertex, Source: vert})
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "the vertex stage is used more than once") {
+		t.Errorf("errors = %v", errs)
+	}
+}
+
+func TestStageIsTheGLEnum(t *testing.T) {
+	//gl.VERTEX_SHADER, gl.GEOMETRY_SHADER and gl.FRAGMENT_SHADER
+	for stage, want := range map[Stage]uint32{Vertex: 0x8B31, Geometry: 0x8DD9, Fragment: 0x8B30} {
+		if uint32(stage) != want {
+			t.Errorf("%s = 0x%X, want 0x%X", stage, uint32(stage), want)
+		}
+	}
+	if s := Stage(0x91B9).String(); s != "stage(0x91B9)" {
+		t.Errorf("unknown stage = %s", s)
+	}
+}
@@ -0,0 +1,87 @@
+package glsl
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var defineNameRegex = regexp.MustCompile(`^[A-Za-z_]\w*$`)
+
+// checks every name is an identifier and no value would end the #define early
+func ValidateDefines(defines map[string]string) error {
+	for name, value := range defines {
+		if !defineNameRegex.MatchString(name) {
+			return fmt.Errorf("invalid define name %q", name)
+		}
+		//a trailing backslash would continue the #define onto the next line
+		if strings.ContainsAny(value, "\r\n") || strings.HasSuffix(value, "\\") {
+			return fmt.Errorf("invalid value %q for define %s", value, name)
+		}
+	}
+	return nil
+}
+
+// adds a #define for each of the defines after the #version line of source
+// a #line directive after them keeps the line numbers in compile logs correct
+func InjectDefines(source string, defines map[string]string) (string, error) {
+	if err := ValidateDefines(defines); err != nil {
+		return "", err
+	}
+	if len(defines) == 0 {
+		return source, nil
+	}
+
+	lines := strings.SplitAfter(source, "\n")
+	versionLine := -1
+	inComment := false
+	for i, line := range lines {
+		if !inComment {
+			if versionRegex.MatchString(line) {
+				versionLine = i
+				break
+			}
+			trimmed := strings.TrimSpace(line)
+			if trimmed != "" && !strings.HasPrefix(trimmed, "//") && !strings.HasPrefix(trimmed, "/*") {
+				break //#version has to come before anything else
+			}
+		}
+		inComment = updateBlockComment(line, inComment)
+	}
+
+	//before 330 a #line directive sets the number of the line after the next one
+	lineOffset := -1
+	if versionLine >= 0 {
+		match := versionRegex.FindStringSubmatch(lines[versionLine])
+		if version, _ := strconv.Atoi(match[1]); version >= 330 {
+			lineOffset = 0
+		}
+	}
+
+	var out strings.Builder
+	for _, line := range lines[:versionLine+1] {
+		out.WriteString(line)
+	}
+	if versionLine >= 0 && !strings.HasSuffix(lines[versionLine], "\n") {
+		out.WriteString("\n")
+	}
+	names := make([]string, 0, len(defines))
+	for name := range defines {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if value := defines[name]; value != "" {
+			fmt.Fprintf(&out, "#define %s %s\n", name, value)
+		} else {
+			fmt.Fprintf(&out, "#define %s\n", name)
+		}
+	}
+	fmt.Fprintf(&out, "#line %d 0\n", versionLine+2+lineOffset)
+	for _, line := range lines[versionLine+1:] {
+		out.WriteString(line)
+	}
+	return out.String(), nil
+}
@@ -0,0 +1,83 @@
+package glsl
+
+import "testing"
+
+func TestInjectDefines(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		defines map[string]string
+		want    string
+	}{
+		{
+			name:   "no defines",
+			source: "#version 330 core\nvoid main() {}\n",
+			want:   "#version 330 core\nvoid main() {}\n",
+		},
+		{
+			name:    "after the version in sorted order",
+			source:  "#version 330 core\nvoid main() {}\n",
+			defines: map[string]string{"NUM_LIGHTS": "4", "NORMAL_MAP": ""},
+			want:    "#version 330 core\n#define NORMAL_MAP\n#define NUM_LIGHTS 4\n#line 2 0\nvoid main() {}\n",
+		},
+		{
+			name:    "comments before the version",
+			source:  "// a shader\n/* with a\n#version 100 in a comment */\n#version 330 core\nvoid main() {}\n",
+			defines: map[string]string{"A": "1"},
+			want:    "// a shader\n/* with a\n#version 100 in a comment */\n#version 330 core\n#define A 1\n#line 5 0\nvoid main() {}\n",
+		},
+		{
+			name:    "before 330 the line is one less",
+			source:  "#version 120\nvoid main() {}\n",
+			defines: map[string]string{"A": ""},
+			want:    "#version 120\n#define A\n#line 1 0\nvoid main() {}\n",
+		},
+		{
+			name:    "no version",
+			source:  "void main() {}\n",
+			defines: map[string]string{"A": ""},
+			want:    "#define A\n#line 0 0\nvoid main() {}\n",
+		},
+		{
+			name:    "version without a newline",
+			source:  "#version 330 core",
+			defines: map[string]string{"A": ""},
+			want:    "#version 330 core\n#define A\n#line 2 0\n",
+		},
+		{
+			name:    "keeps the #line of preprocessed sources",
+			source:  "#version 330 core\n#line 1 1\nfloat x;\n#line 2 0\nvoid main() {}\n",
+			defines: map[string]string{"A": ""},
+			want:    "#version 330 core\n#define A\n#line 2 0\n#line 1 1\nfloat x;\n#line 2 0\nvoid main() {}\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := InjectDefines(test.source, test.defines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != test.want {
+				t.Errorf("got\n%q\nwant\n%q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestInjectDefinesInvalid(t *testing.T) {
+	invalid := []map[string]string{
+		{"": "1"},
+		{"1A": ""},
+		{"A B": ""},
+		{"A\nvoid main() {}": ""},
+		{"A": "1\n#define B 2"},
+		{"A": "1\r"},
+		{"A": "1 \\"},
+	}
+	for _, defines := range invalid {
+		if _, err := InjectDefines("#version 330 core\n", defines); err == nil {
+			t.Errorf("%q should be invalid", defines)
+		}
+	}
+}
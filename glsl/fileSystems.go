@@ -0,0 +1,47 @@
+package glsl
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// the fs.FS used for plain os paths by Preprocess and by the
+// gogl loaders, unlike os.DirFS it accepts absolute and ../ paths
+type OSFS struct{}
+
+func (OSFS) Open(name string) (fs.File, error) {
+	return os.Open(name)
+}
+
+func (OSFS) Stat(name string) (fs.FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OSFS) ReadFile(name string) ([]byte, error) {
+	return os.ReadFile(name)
+}
+
+// joins paths with the os separator for an OSFS and with / for any
+// other file system as fs.FS paths always use /
+func JoinPath(fsys fs.FS, elem ...string) string {
+	if _, ok := fsys.(OSFS); ok {
+		return filepath.Join(elem...)
+	}
+	return path.Join(elem...)
+}
+
+func DirPath(fsys fs.FS, name string) string {
+	if _, ok := fsys.(OSFS); ok {
+		return filepath.Dir(name)
+	}
+	return path.Dir(name)
+}
+
+func CleanPath(fsys fs.FS, name string) string {
+	if _, ok := fsys.(OSFS); ok {
+		return filepath.Clean(name)
+	}
+	return path.Clean(name)
+}
@@ -0,0 +1,366 @@
+package glsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// an in, out or uniform variable of a shader stage
+type Variable struct {
+	Name string
+	Type string // including any array size e.g. vec3[4]
+	// flat, smooth or noperspective, "" when not given which is the same as smooth
+	Interpolation string
+	Location      int    // -1 without layout(location = N)
+	Block         string // the name of the interface or uniform block it's in, if any
+
+	File string
+	Line int
+}
+
+// the variables a stage reads from the stage before it and writes to the next one
+type Interface struct {
+	Stage   Stage
+	Inputs  []Variable
+	Outputs []Variable
+	// the uniforms of the stage including the members of uniform blocks
+	Uniforms []Variable
+	// the members of every struct declared, by the name of the struct
+	Structs map[string][]Variable
+}
+
+// finds the global in, out and uniform variables of a preprocessed shader
+// declarations inside #if blocks are all included since the
+// preprocessor conditionals aren't evaluated
+func ParseInterface(s *Source, stage Stage) Interface {
+	return parseShader(s).parseInterface(stage)
+}
+
+func (p *parsedShader) parseInterface(stage Stage) Interface {
+	iface := Interface{Stage: stage, Structs: make(map[string][]Variable)}
+
+	var statement []token
+	depth := 0
+	for i := 0; i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		switch t.text {
+		case "{":
+			//skip function bodies
+			if depth == 0 && len(statement) > 0 && statement[len(statement)-1].text == ")" {
+				i = skipBlock(p.tokens, i)
+				statement = nil
+				continue
+			}
+			depth++
+		case "}":
+			depth--
+		case ";":
+			if depth == 0 {
+				if len(statement) > 2 && statement[0].text == "struct" && statement[2].text == "{" {
+					end := skipBlock(statement, 2)
+					iface.Structs[statement[1].text] = p.parseMembers(statement[3:end], "uniform", stage)
+					statement = nil
+					continue
+				}
+				storage, vars := p.parseDeclaration(statement, stage)
+				switch storage {
+				case "in":
+					iface.Inputs = append(iface.Inputs, vars...)
+				case "out":
+					iface.Outputs = append(iface.Outputs, vars...)
+				case "uniform":
+					iface.Uniforms = append(iface.Uniforms, vars...)
+				}
+				statement = nil
+				continue
+			}
+		}
+		statement = append(statement, t)
+	}
+	return iface
+}
+
+// the index of the } closing the { at start
+func skipBlock(tokens []token, start int) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens)
+}
+
+// reads the qualifiers, type and names of a global declaration
+// storage is "in" or "out" for the variables passed between stages and "uniform" for uniforms
+func (p *parsedShader) parseDeclaration(statement []token, stage Stage) (storage string, vars []Variable) {
+	location := -1
+	interpolation := ""
+
+	i := 0
+qualifiers:
+	for ; i < len(statement); i++ {
+		switch word := statement[i].text; word {
+		case "layout":
+			end := matchingParen(statement, i+1)
+			for j := i + 1; j+2 < end; j++ {
+				if statement[j].text == "location" && statement[j+1].text == "=" {
+					if n, err := strconv.Atoi(statement[j+2].text); err == nil {
+						location = n
+					}
+				}
+			}
+			i = end
+		case "flat", "smooth", "noperspective":
+			interpolation = word
+		case "centroid", "sample", "invariant", "precise", "patch", "highp", "mediump", "lowp":
+		case "in", "out", "uniform", "const", "buffer", "inout", "shared":
+			storage = word
+		case "attribute":
+			storage = "in"
+		case "varying":
+			storage = "out"
+			if stage == Fragment {
+				storage = "in"
+			}
+		default:
+			break qualifiers
+		}
+	}
+	if storage != "in" && storage != "out" && storage != "uniform" {
+		return storage, nil
+	}
+	rest := statement[i:]
+	if len(rest) == 0 {
+		return storage, nil //e.g. layout(triangles) in;
+	}
+
+	//an interface block like out VS_OUT { vec3 normal; } vs_out;
+	if len(rest) > 1 && rest[1].text == "{" {
+		block := rest[0].text
+		if strings.HasPrefix(block, "gl_") {
+			return storage, nil
+		}
+		end := skipBlock(rest, 1)
+
+		for _, v := range p.parseMembers(rest[2:end], storage, stage) {
+			v.Block = block
+			if v.Interpolation == "" {
+				v.Interpolation = interpolation
+			}
+			vars = append(vars, v)
+		}
+		return storage, vars
+	}
+
+	typeName := rest[0].text
+	i = 1
+	for i < len(rest) && rest[i].text == "[" {
+		end := matchingBracket(rest, i)
+		typeName += joinTokens(rest[i : end+1])
+		i = end + 1
+	}
+
+	for i < len(rest) {
+		name := rest[i]
+		i++
+		varType := typeName
+		for i < len(rest) && rest[i].text == "[" {
+			end := matchingBracket(rest, i)
+			varType += joinTokens(rest[i : end+1])
+			i = end + 1
+		}
+		//an initializer which only uniforms can have
+		for i < len(rest) && rest[i].text != "," {
+			if rest[i].text == "(" {
+				i = matchingParen(rest, i)
+			}
+			i++
+		}
+		i++
+
+		if strings.HasPrefix(name.text, "gl_") {
+			continue
+		}
+		file, line := p.source.position(p.positions[name.line])
+		vars = append(vars, Variable{
+			Name:          name.text,
+			Type:          varType,
+			Interpolation: interpolation,
+			Location:      location,
+			File:          file,
+			Line:          line,
+		})
+	}
+	return storage, vars
+}
+
+// the variables declared between the braces of a block or struct
+// as if each declaration had the storage qualifier
+func (p *parsedShader) parseMembers(body []token, storage string, stage Stage) []Variable {
+	var vars []Variable
+	var members []token
+	for _, t := range body {
+		if t.text == ";" {
+			_, memberVars := p.parseDeclaration(append([]token{{text: storage}}, members...), stage)
+			vars = append(vars, memberVars...)
+			members = nil
+			continue
+		}
+		members = append(members, t)
+	}
+	return vars
+}
+
+func matchingParen(tokens []token, start int) int {
+	return matchingToken(tokens, start, "(", ")")
+}
+
+func matchingBracket(tokens []token, start int) int {
+	return matchingToken(tokens, start, "[", "]")
+}
+
+func matchingToken(tokens []token, start int, open, close string) int {
+	depth := 0
+	for i := start; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return len(tokens) - 1
+}
+
+func joinTokens(tokens []token) string {
+	var s strings.Builder
+	for _, t := range tokens {
+		s.WriteString(t.text)
+	}
+	return s.String()
+}
+
+// checks that every input of next is an output of prev with the same type and interpolation
+// outputs nothing reads are allowed
+func CheckInterface(prev, next Interface) []error {
+	var errs []error
+	missingBlocks := make(map[string]bool)
+
+	for _, input := range next.Inputs {
+		inputType := input.Type
+		if next.Stage == Geometry && input.Block == "" {
+			//geometry inputs are arrays with an element per vertex
+			inputType = stripArray(inputType)
+		}
+
+		var output *Variable
+		if input.Block != "" {
+			if !hasBlock(prev.Outputs, input.Block) {
+				if !missingBlocks[input.Block] {
+					missingBlocks[input.Block] = true
+					errs = append(errs, interfaceError(input, "%s input block %s isn't an output of the %s shader", next.Stage, input.Block, prev.Stage))
+				}
+				continue
+			}
+			output = findOutput(prev.Outputs, input, false)
+		} else {
+			output = findOutput(prev.Outputs, input, true)
+		}
+
+		if output == nil {
+			errs = append(errs, interfaceError(input, "%s input %s isn't an output of the %s shader", next.Stage, displayName(input), prev.Stage))
+			continue
+		}
+		if output.Type != inputType {
+			errs = append(errs, interfaceError(input, "%s input %s is a %s but the %s shader outputs a %s at %s:%d",
+				next.Stage, displayName(input), inputType, prev.Stage, output.Type, output.File, output.Line))
+		}
+		if interpolationName(output.Interpolation) != interpolationName(input.Interpolation) {
+			errs = append(errs, interfaceError(input, "%s input %s is %s but the %s shader output is %s",
+				next.Stage, displayName(input), interpolationName(input.Interpolation), prev.Stage, interpolationName(output.Interpolation)))
+		}
+	}
+
+	if next.Stage == Fragment {
+		for _, input := range next.Inputs {
+			if isIntegerType(input.Type) && input.Interpolation != "flat" {
+				errs = append(errs, interfaceError(input, "integer fragment input %s has to be flat", displayName(input)))
+			}
+		}
+	}
+	return errs
+}
+
+func findOutput(outputs []Variable, input Variable, useLocation bool) *Variable {
+	if useLocation && input.Location >= 0 {
+		for i, output := range outputs {
+			if output.Block == "" && output.Location == input.Location {
+				return &outputs[i]
+			}
+		}
+	}
+	for i, output := range outputs {
+		if output.Block == input.Block && output.Name == input.Name {
+			return &outputs[i]
+		}
+	}
+	return nil
+}
+
+func hasBlock(outputs []Variable, block string) bool {
+	for _, output := range outputs {
+		if output.Block == block {
+			return true
+		}
+	}
+	return false
+}
+
+func stripArray(typeName string) string {
+	if start := strings.LastIndex(typeName, "["); start >= 0 && strings.HasSuffix(typeName, "]") {
+		return typeName[:start]
+	}
+	return typeName
+}
+
+func isIntegerType(typeName string) bool {
+	typeName = strings.SplitN(typeName, "[", 2)[0]
+	switch typeName {
+	case "int", "uint":
+		return true
+	}
+	return strings.HasPrefix(typeName, "ivec") || strings.HasPrefix(typeName, "uvec")
+}
+
+func interpolationName(interpolation string) string {
+	if interpolation == "" {
+		return "smooth"
+	}
+	return interpolation
+}
+
+func displayName(v Variable) string {
+	if v.Block != "" {
+		return v.Block + "." + v.Name
+	}
+	return v.Name
+}
+
+func interfaceError(v Variable, format string, args ...any) error {
+	return &Error{
+		File:    v.File,
+		Line:    v.Line,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
@@ -0,0 +1,46 @@
+package glsl
+
+import "testing"
+
+func TestParseInterfaceUniforms(t *testing.T) {
+	source := &Source{Source: `#version 330 core
+struct Light {
+	vec3 position;
+	float strength[2];
+};
+uniform Light lights[4];
+uniform vec3 tint = vec3(1, 0.5, 0), fog;
+layout (std140) uniform Camera {
+	mat4 view;
+};
+in vec2 vUV;
+out vec4 color;
+float brightness(vec3 c) { return dot(c, vec3(0.3, 0.6, 0.1)); }
+void main() { color = vec4(tint * brightness(fog), 1); }
+`}
+	iface := ParseInterface(source, Fragment)
+
+	want := []struct{ name, typeName, block string }{
+		{"lights", "Light[4]", ""},
+		{"tint", "vec3", ""},
+		{"fog", "vec3", ""},
+		{"view", "mat4", "Camera"},
+	}
+	if len(iface.Uniforms) != len(want) {
+		t.Fatalf("uniforms = %+v", iface.Uniforms)
+	}
+	for i, w := range want {
+		u := iface.Uniforms[i]
+		if u.Name != w.name || u.Type != w.typeName || u.Block != w.block {
+			t.Errorf("uniform %d = %s %s in %q, want %s %s in %q", i, u.Type, u.Name, u.Block, w.typeName, w.name, w.block)
+		}
+	}
+	if len(iface.Inputs) != 1 || len(iface.Outputs) != 1 {
+		t.Errorf("inputs %+v and outputs %+v", iface.Inputs, iface.Outputs)
+	}
+
+	members := iface.Structs["Light"]
+	if len(members) != 2 || members[0].Name != "position" || members[1].Type != "float[2]" {
+		t.Errorf("Light members = %+v", members)
+	}
+}
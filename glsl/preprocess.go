@@ -0,0 +1,220 @@
+// Package glsl works with glsl source without needing a gl context.
+// It expands #include directives the same way gogl does when loading
+// shaders and checks sources for common errors, which lets shaders
+// be validated on machines without a gpu.
+package glsl
+
+import (
+	"fmt"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// a shader after all of its #include directives have been expanded
+// the index of a file in Files is the source string number
+// used for it in the #line directives of Source
+type Source struct {
+	Source string
+	Files  []string
+}
+
+// expands every #include "file" in the shader at path
+// included paths are relative to the file that includes them
+// files with #pragma once or an include guard are only included once
+func Preprocess(path string) (*Source, error) {
+	return PreprocessFS(OSFS{}, path)
+}
+
+// same as Preprocess but reads the shader and its includes from fsys
+func PreprocessFS(fsys fs.FS, path string) (*Source, error) {
+	p := shaderPreprocessor{
+		fsys:      fsys,
+		fileIndex: make(map[string]int),
+		once:      make(map[string]bool),
+	}
+
+	err := p.process(CleanPath(fsys, path))
+	return &Source{
+		Source: p.out.String(),
+		Files:  p.files,
+	}, err
+}
+
+var (
+	includeRegex = regexp.MustCompile(`^\s*#\s*include\s*["<]([^">]+)[">]`)
+	versionRegex = regexp.MustCompile(`^\s*#\s*version\s+(\d+)`)
+	pragmaOnce   = regexp.MustCompile(`^\s*#\s*pragma\s+once\b`)
+	ifndefRegex  = regexp.MustCompile(`^\s*#\s*ifndef\s+(\w+)`)
+	defineRegex  = regexp.MustCompile(`^\s*#\s*define\s+(\w+)`)
+)
+
+type shaderPreprocessor struct {
+	fsys fs.FS
+
+	files     []string
+	fileIndex map[string]int
+	once      map[string]bool
+	stack     []string
+
+	out strings.Builder
+	// before 330 a #line directive sets the number of the line after the next one
+	lineOffset int
+}
+
+func (p *shaderPreprocessor) process(path string) error {
+	if p.once[path] {
+		return nil
+	}
+	for i, included := range p.stack {
+		if included == path {
+			cycle := append(append([]string{}, p.stack[i:]...), path)
+			return fmt.Errorf("include cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	index, ok := p.fileIndex[path]
+	if !ok {
+		index = len(p.files)
+		p.fileIndex[path] = index
+		p.files = append(p.files, path)
+	}
+
+	data, err := fs.ReadFile(p.fsys, path)
+	if err != nil {
+		return err
+	}
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if includeOnce(lines) {
+		p.once[path] = true
+	}
+
+	p.stack = append(p.stack, path)
+	defer func() { p.stack = p.stack[:len(p.stack)-1] }()
+
+	isRoot := len(p.stack) == 1
+	if !isRoot {
+		p.writeLine(1, index)
+	}
+
+	inComment := false
+	for i, line := range lines {
+		lineNumber := i + 1
+		if i == len(lines)-1 && line == "" {
+			break
+		}
+
+		if !inComment {
+			if match := versionRegex.FindStringSubmatch(line); match != nil {
+				if !isRoot {
+					p.out.WriteString("// " + strings.TrimSpace(line) + "\n")
+					continue
+				}
+				if version, _ := strconv.Atoi(match[1]); version < 330 {
+					p.lineOffset = -1
+				}
+				p.out.WriteString(line + "\n")
+				continue
+			}
+
+			if pragmaOnce.MatchString(line) {
+				p.out.WriteString("\n")
+				continue
+			}
+
+			if match := includeRegex.FindStringSubmatch(line); match != nil {
+				includePath := CleanPath(p.fsys, JoinPath(p.fsys, DirPath(p.fsys, path), match[1]))
+				if err := p.process(includePath); err != nil {
+					return fmt.Errorf("%s:%d: %w", path, lineNumber, err)
+				}
+				p.writeLine(lineNumber+1, index)
+				continue
+			}
+		}
+		inComment = updateBlockComment(line, inComment)
+
+		p.out.WriteString(line + "\n")
+	}
+	return nil
+}
+
+// makes the line after the directive report as line in file
+func (p *shaderPreprocessor) writeLine(line, file int) {
+	fmt.Fprintf(&p.out, "#line %d %d\n", line+p.lineOffset, file)
+}
+
+// reports whether the file should only be included once
+// either from #pragma once or a #ifndef X #define X guard
+func includeOnce(lines []string) bool {
+	var directives []string
+	inComment := false
+	for _, line := range lines {
+		wasComment := inComment
+		inComment = updateBlockComment(line, inComment)
+
+		trimmed := strings.TrimSpace(line)
+		if wasComment || trimmed == "" || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*") {
+			continue
+		}
+		if pragmaOnce.MatchString(line) {
+			return true
+		}
+		directives = append(directives, line)
+		if len(directives) == 2 {
+			break
+		}
+	}
+
+	if len(directives) < 2 {
+		return false
+	}
+	guard := ifndefRegex.FindStringSubmatch(directives[0])
+	define := defineRegex.FindStringSubmatch(directives[1])
+	return guard != nil && define != nil && guard[1] == define[1]
+}
+
+// tracks whether a /* comment is still open at the end of the line
+func updateBlockComment(line string, inComment bool) bool {
+	for {
+		if inComment {
+			end := strings.Index(line, "*/")
+			if end < 0 {
+				return true
+			}
+			line = line[end+2:]
+			inComment = false
+		} else {
+			start := strings.Index(line, "/*")
+			lineComment := strings.Index(line, "//")
+			if start < 0 || (lineComment >= 0 && lineComment < start) {
+				return false
+			}
+			line = line[start+2:]
+			inComment = true
+		}
+	}
+}
+
+// matches the source string and line number at the start of the
+// log messages from the common drivers e.g. mesa's "0:12(5): error"
+// nvidia's "0(12) : error" and amd's "ERROR: 0:12: "
+var logLocationRegex = regexp.MustCompile(`(?m)^((?:ERROR|WARNING): )?(\d+)(?::(\d+)|\((\d+)\))`)
+
+// rewrites the source string numbers in a compile log
+// into the paths of the files they came from
+func (s *Source) RemapLog(log string) string {
+	return logLocationRegex.ReplaceAllStringFunc(log, func(location string) string {
+		match := logLocationRegex.FindStringSubmatch(location)
+		file, _ := strconv.Atoi(match[2])
+		if file < 0 || file >= len(s.Files) {
+			return location
+		}
+
+		line := match[3]
+		if line == "" {
+			line = match[4]
+		}
+		return fmt.Sprintf("%s%s:%s", match[1], s.Files[file], line)
+	})
+}
@@ -0,0 +1,164 @@
+package glsl
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestPreprocessIncludes(t *testing.T) {
+	files := fstest.MapFS{
+		"shaders/main.frag": {Data: []byte(`#version 330 core
+#include "lib/light.glsl"
+#include "lib/util.glsl"
+out vec4 color;
+void main() { color = light(); }
+`)},
+		"shaders/lib/light.glsl": {Data: []byte(`#pragma once
+#include "util.glsl"
+vec4 light() { return vec4(saturate(2.0)); }
+`)},
+		"shaders/lib/util.glsl": {Data: []byte(`#ifndef UTIL
+#define UTIL
+float saturate(float x) { return clamp(x, 0.0, 1.0); }
+#endif
+`)},
+	}
+
+	//util.glsl has an include guard so the second include of it is skipped
+	source, err := PreprocessFS(files, "shaders/main.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantFiles := []string{"shaders/main.frag", "shaders/lib/light.glsl", "shaders/lib/util.glsl"}
+	if !slices.Equal(source.Files, wantFiles) {
+		t.Errorf("files = %v, want %v", source.Files, wantFiles)
+	}
+	want := `#version 330 core
+#line 1 1
+
+#line 1 2
+#ifndef UTIL
+#define UTIL
+float saturate(float x) { return clamp(x, 0.0, 1.0); }
+#endif
+#line 3 1
+vec4 light() { return vec4(saturate(2.0)); }
+#line 3 0
+#line 4 0
+out vec4 color;
+void main() { color = light(); }
+`
+	if source.Source != want {
+		t.Errorf("source is\n%s\nwant\n%s", source.Source, want)
+	}
+}
+
+func TestPreprocessIncludeErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     fstest.MapFS
+		wantError string
+	}{
+		{
+			name: "cycle",
+			files: fstest.MapFS{
+				"main.vert": {Data: []byte("#version 330 core\n#include \"a.glsl\"\n")},
+				"a.glsl":    {Data: []byte("#include \"b.glsl\"\n")},
+				"b.glsl":    {Data: []byte("\n#include \"a.glsl\"\n")},
+			},
+			wantError: "main.vert:2: a.glsl:1: b.glsl:2: include cycle: a.glsl -> b.glsl -> a.glsl",
+		},
+		{
+			name: "self include",
+			files: fstest.MapFS{
+				"main.vert": {Data: []byte("#version 330 core\n#include \"main.vert\"\n")},
+			},
+			wantError: "include cycle: main.vert -> main.vert",
+		},
+		{
+			name: "missing file",
+			files: fstest.MapFS{
+				"main.vert": {Data: []byte("#version 330 core\n\n#include <missing.glsl>\n")},
+			},
+			wantError: "main.vert:3: open missing.glsl",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			source, err := PreprocessFS(test.files, "main.vert")
+			if err == nil || !strings.Contains(err.Error(), test.wantError) {
+				t.Fatalf("got error %v, want one containing %q", err, test.wantError)
+			}
+			if len(source.Files) == 0 || source.Files[0] != "main.vert" {
+				t.Errorf("the files read before the error should still be listed, got %v", source.Files)
+			}
+		})
+	}
+}
+
+func TestPreprocessLineRemapping(t *testing.T) {
+	files := fstest.MapFS{
+		"main.vert": {Data: []byte(`#version 330 core
+#include "util.glsl"
+
+void main() {
+	gl_Position = vec4(0);
+#bogus
+}
+`)},
+		"util.glsl": {Data: []byte(`// helpers
+float twice(float x) {
+#nonsense
+	return x * 2.0;
+}
+`)},
+	}
+
+	source, err := PreprocessFS(files, "main.vert")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Error
+	for _, err := range CheckSyntax(source) {
+		var glslErr *Error
+		if !errors.As(err, &glslErr) {
+			t.Fatalf("%v isn't an *Error", err)
+		}
+		got = append(got, *glslErr)
+	}
+	want := []Error{
+		{"util.glsl", 3, "unknown preprocessor directive #nonsense"},
+		{"main.vert", 6, "unknown preprocessor directive #bogus"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("errors = %v, want %v", got, want)
+	}
+
+	log := "0:3(2): error: syntax error\n1:4(9): error: x undeclared\n0(5) : error C0000\nERROR: 1:2: oops\n7:1(1): out of range"
+	wantLog := "main.vert:3(2): error: syntax error\nutil.glsl:4(9): error: x undeclared\nmain.vert:5 : error C0000\nERROR: util.glsl:2: oops\n7:1(1): out of range"
+	if remapped := source.RemapLog(log); remapped != wantLog {
+		t.Errorf("remapped log is\n%s\nwant\n%s", remapped, wantLog)
+	}
+}
+
+func TestPreprocessLineBefore330(t *testing.T) {
+	files := fstest.MapFS{
+		"main.vert": {Data: []byte("#version 120\n#include \"util.glsl\"\nvoid main() {}\n")},
+		"util.glsl": {Data: []byte("float x;\n")},
+	}
+	source, err := PreprocessFS(files, "main.vert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//before 330 #line gives the number of the line after the next one
+	want := "#version 120\n#line 0 1\nfloat x;\n#line 2 0\nvoid main() {}\n"
+	if source.Source != want {
+		t.Errorf("source is\n%s\nwant\n%s", source.Source, want)
+	}
+}
@@ -0,0 +1,144 @@
+package glsl
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type token struct {
+	text string
+	line int // the line of the preprocessed source, starting at 0
+}
+
+type directive struct {
+	name string // e.g. "ifdef" or "" for a lone #
+	text string
+	line int
+}
+
+var (
+	directiveRegex = regexp.MustCompile(`^\s*#\s*(\w*)`)
+	lineRegex      = regexp.MustCompile(`^\s*#\s*line\s+(\d+)(?:\s+(\d+))?`)
+)
+
+// splits source into tokens and preprocessor directives, dropping the comments
+// numbers are kept as a single token but operators are split into single characters
+func tokenize(source string) ([]token, []directive) {
+	var tokens []token
+	var directives []directive
+
+	inComment := false
+	for i, line := range strings.Split(source, "\n") {
+		if !inComment {
+			if match := directiveRegex.FindStringSubmatch(line); match != nil {
+				directives = append(directives, directive{match[1], strings.TrimSpace(line), i})
+				inComment = updateBlockComment(line, false)
+				continue
+			}
+		}
+
+		for pos := 0; pos < len(line); {
+			if inComment {
+				end := strings.Index(line[pos:], "*/")
+				if end < 0 {
+					break
+				}
+				pos += end + 2
+				inComment = false
+				continue
+			}
+
+			c := line[pos]
+			switch {
+			case strings.HasPrefix(line[pos:], "//"):
+				pos = len(line)
+			case strings.HasPrefix(line[pos:], "/*"):
+				pos += 2
+				inComment = true
+			case c == ' ' || c == '\t' || c == '\r':
+				pos++
+			case isWordChar(c):
+				start := pos
+				for pos < len(line) && (isWordChar(line[pos]) || line[pos] == '.') {
+					pos++
+				}
+				tokens = append(tokens, token{line[start:pos], i})
+			default:
+				tokens = append(tokens, token{string(c), i})
+				pos++
+			}
+		}
+	}
+	return tokens, directives
+}
+
+func isWordChar(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// where a line of the preprocessed source came from
+type position struct {
+	file int
+	line int
+}
+
+// follows the #line directives to find the original
+// file and line of every line in source
+func linePositions(source string) []position {
+	lines := strings.Split(source, "\n")
+	positions := make([]position, len(lines))
+
+	lineOffset := 0
+	file, line := 0, 1
+	for i, text := range lines {
+		positions[i] = position{file, line}
+		line++
+
+		if match := versionRegex.FindStringSubmatch(text); match != nil {
+			if version, _ := strconv.Atoi(match[1]); version < 330 {
+				//before 330 a #line directive sets the number of the line after the next one
+				lineOffset = -1
+			}
+		}
+		if match := lineRegex.FindStringSubmatch(text); match != nil {
+			n, _ := strconv.Atoi(match[1])
+			line = n - lineOffset
+			if match[2] != "" {
+				file, _ = strconv.Atoi(match[2])
+			}
+		}
+	}
+	return positions
+}
+
+// the file and line a line of the preprocessed source came from
+// line starts at 1 like the line numbers in compile logs
+func (s *Source) Location(line int) (string, int) {
+	positions := linePositions(s.Source)
+	if line < 1 || line > len(positions) {
+		return "", line
+	}
+	return s.position(positions[line-1])
+}
+
+func (s *Source) position(p position) (string, int) {
+	if p.file < 0 || p.file >= len(s.Files) {
+		return "", p.line
+	}
+	return s.Files[p.file], p.line
+}
+
+// the #version of source or 110, the version used when there isn't one
+func sourceVersion(directives []directive) int {
+	for _, d := range directives {
+		if d.name == "version" {
+			match := versionRegex.FindStringSubmatch(d.text)
+			if match != nil {
+				version, _ := strconv.Atoi(match[1])
+				return version
+			}
+		}
+	}
+	return 110
+}
@@ -0,0 +1,1032 @@
+package gogl
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"math"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+// the raw json structure of a glTF 2.0 file
+// only the parts that are needed for drawing are decoded
+type gltfDocument struct {
+	ExtensionsRequired []string `json:"extensionsRequired"`
+
+	Scene  *int `json:"scene"`
+	Scenes []struct {
+		Nodes []int `json:"nodes"`
+	} `json:"scenes"`
+	Nodes []struct {
+		Name        string    `json:"name"`
+		Children    []int     `json:"children"`
+		Mesh        *int      `json:"mesh"`
+		Matrix      []float32 `json:"matrix"`
+		Translation []float32 `json:"translation"`
+		Rotation    []float32 `json:"rotation"`
+		Scale       []float32 `json:"scale"`
+	} `json:"nodes"`
+	Meshes []struct {
+		Name       string `json:"name"`
+		Primitives []struct {
+			Attributes map[string]int `json:"attributes"`
+			Indices    *int           `json:"indices"`
+			Material   *int           `json:"material"`
+			Mode       *int           `json:"mode"`
+		} `json:"primitives"`
+	} `json:"meshes"`
+	Materials []struct {
+		Name                 string `json:"name"`
+		PBRMetallicRoughness struct {
+			BaseColorFactor          []float32        `json:"baseColorFactor"`
+			BaseColorTexture         *gltfTextureInfo `json:"baseColorTexture"`
+			MetallicFactor           *float32         `json:"metallicFactor"`
+			RoughnessFactor          *float32         `json:"roughnessFactor"`
+			MetallicRoughnessTexture *gltfTextureInfo `json:"metallicRoughnessTexture"`
+		} `json:"pbrMetallicRoughness"`
+		NormalTexture    *gltfTextureInfo `json:"normalTexture"`
+		OcclusionTexture *gltfTextureInfo `json:"occlusionTexture"`
+		EmissiveTexture  *gltfTextureInfo `json:"emissiveTexture"`
+		EmissiveFactor   []float32        `json:"emissiveFactor"`
+		AlphaMode        string           `json:"alphaMode"`
+		AlphaCutoff      *float32         `json:"alphaCutoff"`
+		DoubleSided      bool             `json:"doubleSided"`
+	} `json:"materials"`
+	Textures []struct {
+		Sampler *int `json:"sampler"`
+		Source  *int `json:"source"`
+	} `json:"textures"`
+	Samplers []struct {
+		MagFilter int32 `json:"magFilter"`
+		MinFilter int32 `json:"minFilter"`
+		WrapS     int32 `json:"wrapS"`
+		WrapT     int32 `json:"wrapT"`
+	} `json:"samplers"`
+	Images []struct {
+		URI        string `json:"uri"`
+		BufferView *int   `json:"bufferView"`
+		MimeType   string `json:"mimeType"`
+	} `json:"images"`
+	Accessors   []gltfAccessor `json:"accessors"`
+	BufferViews []struct {
+		Buffer     int `json:"buffer"`
+		ByteOffset int `json:"byteOffset"`
+		ByteLength int `json:"byteLength"`
+		ByteStride int `json:"byteStride"`
+	} `json:"bufferViews"`
+	Buffers []struct {
+		URI        string `json:"uri"`
+		ByteLength int    `json:"byteLength"`
+	} `json:"buffers"`
+}
+
+type gltfTextureInfo struct {
+	Index    int      `json:"index"`
+	Scale    *float32 `json:"scale"`
+	Strength *float32 `json:"strength"`
+}
+
+type gltfAccessor struct {
+	BufferView    *int   `json:"bufferView"`
+	ByteOffset    int    `json:"byteOffset"`
+	ComponentType int    `json:"componentType"`
+	Normalized    bool   `json:"normalized"`
+	Count         int    `json:"count"`
+	Type          string `json:"type"`
+	Sparse        *struct {
+		Count   int `json:"count"`
+		Indices struct {
+			BufferView    int `json:"bufferView"`
+			ByteOffset    int `json:"byteOffset"`
+			ComponentType int `json:"componentType"`
+		} `json:"indices"`
+		Values struct {
+			BufferView int `json:"bufferView"`
+			ByteOffset int `json:"byteOffset"`
+		} `json:"values"`
+	} `json:"sparse"`
+}
+
+const (
+	gltfByte          = 5120
+	gltfUnsignedByte  = 5121
+	gltfShort         = 5122
+	gltfUnsignedShort = 5123
+	gltfUnsignedInt   = 5125
+	gltfFloat         = 5126
+
+	glbMagic     = 0x46546C67 // glTF
+	glbChunkJSON = 0x4E4F534A
+	glbChunkBIN  = 0x004E4942
+)
+
+// the metallic roughness material model of glTF
+// textures are indices into the Textures of the scene or -1 if unused
+type PBRMaterial struct {
+	Name string
+
+	BaseColorFactor mgl32.Vec4
+	MetallicFactor  float32
+	RoughnessFactor float32
+	EmissiveFactor  mgl32.Vec3
+
+	BaseColorTexture         int
+	MetallicRoughnessTexture int
+	NormalTexture            int
+	NormalScale              float32
+	OcclusionTexture         int
+	OcclusionStrength        float32
+	EmissiveTexture          int
+
+	AlphaMode   string // OPAQUE, MASK or BLEND
+	AlphaCutoff float32
+	DoubleSided bool
+}
+
+type GLTFPrimitive struct {
+	Verticies []float32 //in XYZ UV
+	Normals   []float32
+	Indices   []uint32
+	Material  int // -1 if the primitive has no material
+}
+
+type GLTFMesh struct {
+	Name       string
+	Primitives []GLTFPrimitive
+}
+
+// an image and the sampler state it should be uploaded with
+// filter and wrap values are gl enums or 0 for the default
+type GLTFTexture struct {
+	Image     image.Image
+	MagFilter int32
+	MinFilter int32
+	WrapS     int32
+	WrapT     int32
+}
+
+type SceneNode struct {
+	Name     string
+	Mesh     int // -1 if the node has no mesh
+	Local    mgl32.Mat4
+	World    mgl32.Mat4
+	Parent   *SceneNode
+	Children []*SceneNode
+}
+
+// the decoded contents of a glTF file before anything is sent to the gpu
+type GLTFData struct {
+	Meshes    []GLTFMesh
+	Materials []PBRMaterial
+	Textures  []GLTFTexture
+	Nodes     []*SceneNode
+	Roots     []*SceneNode
+}
+
+type ScenePrimitive struct {
+	Object   Object
+	Material int
+}
+
+// a glTF scene uploaded to the gpu
+type Scene struct {
+	Meshes    [][]ScenePrimitive
+	Materials []PBRMaterial
+	Textures  []TextureID
+	Nodes     []*SceneNode
+	Roots     []*SceneNode
+}
+
+// loads a .gltf or .glb file along with every buffer and image it references
+func LoadGLTF(path string) (*Scene, error) {
+	return LoadGLTFFS(glsl.OSFS{}, path)
+}
+
+// same as LoadGLTF but reads the file and everything it references from fsys
+func LoadGLTFFS(fsys fs.FS, path string) (*Scene, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	sceneData, err := decodeGLTF(data, fsys, glsl.DirPath(fsys, path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return sceneData.Upload(), nil
+}
+
+// decodes a .gltf or .glb file without needing a gl context
+// external buffers and images are resolved relative to dir
+func DecodeGLTF(data []byte, dir string) (*GLTFData, error) {
+	return decodeGLTF(data, glsl.OSFS{}, dir)
+}
+
+func decodeGLTF(data []byte, fsys fs.FS, dir string) (*GLTFData, error) {
+	var binChunk []byte
+	jsonChunk := data
+	if len(data) >= 4 && binary.LittleEndian.Uint32(data) == glbMagic {
+		var err error
+		jsonChunk, binChunk, err = readGLB(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	d := gltfDecoder{fsys: fsys, dir: dir}
+	if err := json.Unmarshal(jsonChunk, &d.doc); err != nil {
+		return nil, err
+	}
+	if len(d.doc.ExtensionsRequired) > 0 {
+		return nil, fmt.Errorf("unsupported required extensions %v", d.doc.ExtensionsRequired)
+	}
+
+	if err := d.loadBuffers(binChunk); err != nil {
+		return nil, err
+	}
+
+	out := GLTFData{}
+	var err error
+	if out.Textures, err = d.decodeTextures(); err != nil {
+		return nil, err
+	}
+	if out.Materials, err = d.decodeMaterials(len(out.Textures)); err != nil {
+		return nil, err
+	}
+	if out.Meshes, err = d.decodeMeshes(len(out.Materials)); err != nil {
+		return nil, err
+	}
+	if out.Nodes, out.Roots, err = d.decodeNodes(len(out.Meshes)); err != nil {
+		return nil, err
+	}
+
+	return &out, nil
+}
+
+// uploads every mesh and texture to the gpu
+func (d *GLTFData) Upload() *Scene {
+	s := Scene{
+		Materials: d.Materials,
+		Nodes:     d.Nodes,
+		Roots:     d.Roots,
+	}
+
+	s.Textures = make([]TextureID, len(d.Textures))
+	for i, t := range d.Textures {
+		s.Textures[i] = t.upload()
+	}
+
+	s.Meshes = make([][]ScenePrimitive, len(d.Meshes))
+	for i, mesh := range d.Meshes {
+		for _, p := range mesh.Primitives {
+			o := Object{
+				Type:         mesh.Name,
+				Verticies:    p.Verticies,
+				VertexStride: 5,
+				Indices:      p.Indices,
+				normals:      p.Normals,
+			}
+			o.FillBuffers()
+
+			s.Meshes[i] = append(s.Meshes[i], ScenePrimitive{
+				Object:   o,
+				Material: p.Material,
+			})
+		}
+	}
+
+	return &s
+}
+
+func (t GLTFTexture) upload() TextureID {
+	texture := LoadTextureFromImage(t.Image)
+	if t.WrapS != 0 {
+		ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, t.WrapS)
+	}
+	if t.WrapT != 0 {
+		ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, t.WrapT)
+	}
+	if t.MinFilter != 0 {
+		ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, t.MinFilter)
+	}
+	if t.MagFilter != 0 {
+		ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, t.MagFilter)
+	}
+	return texture
+}
+
+// draws every mesh under the scene's roots with its node's world transform
+// nodes that belong to other scenes in the file aren't drawn
+func (s *Scene) Draw(shader Shader) {
+	for _, root := range s.Roots {
+		s.drawNode(shader, root)
+	}
+}
+
+func (s *Scene) drawNode(shader Shader, node *SceneNode) {
+	if node.Mesh >= 0 {
+		for _, p := range s.Meshes[node.Mesh] {
+			p.Object.Draw(shader, node.World)
+		}
+	}
+	for _, child := range node.Children {
+		s.drawNode(shader, child)
+	}
+}
+
+// frees every object and texture of the scene
+func (s *Scene) Delete() {
+	for _, mesh := range s.Meshes {
+		for i := range mesh {
+			mesh[i].Object.Delete()
+		}
+	}
+	for _, texture := range s.Textures {
+		texture.Delete()
+	}
+	s.Meshes = nil
+	s.Textures = nil
+}
+
+// recalculates the World matrix of every node from the Local
+// matrices, needed after changing any node's Local transform
+func (s *Scene) UpdateTransforms() {
+	for _, root := range s.Roots {
+		root.updateWorld(mgl32.Ident4())
+	}
+}
+
+func (n *SceneNode) updateWorld(parent mgl32.Mat4) {
+	n.World = parent.Mul4(n.Local)
+	for _, child := range n.Children {
+		child.updateWorld(n.World)
+	}
+}
+
+func readGLB(data []byte) (jsonChunk, binChunk []byte, err error) {
+	if len(data) < 12 {
+		return nil, nil, errors.New("glb header is truncated")
+	}
+	if version := binary.LittleEndian.Uint32(data[4:]); version != 2 {
+		return nil, nil, fmt.Errorf("unsupported glb version %d", version)
+	}
+	length := int(binary.LittleEndian.Uint32(data[8:]))
+	if length > len(data) {
+		return nil, nil, errors.New("glb file is truncated")
+	}
+
+	offset := 12
+	for offset+8 <= length {
+		chunkLength := int(binary.LittleEndian.Uint32(data[offset:]))
+		chunkType := binary.LittleEndian.Uint32(data[offset+4:])
+		offset += 8
+		if offset+chunkLength > length {
+			return nil, nil, errors.New("glb chunk is truncated")
+		}
+		chunk := data[offset : offset+chunkLength]
+		offset += chunkLength
+
+		switch chunkType {
+		case glbChunkJSON:
+			if jsonChunk == nil {
+				jsonChunk = chunk
+			}
+		case glbChunkBIN:
+			if binChunk == nil {
+				binChunk = chunk
+			}
+		}
+		// unknown chunks must be ignored
+	}
+
+	if jsonChunk == nil {
+		return nil, nil, errors.New("glb has no json chunk")
+	}
+	return jsonChunk, binChunk, nil
+}
+
+type gltfDecoder struct {
+	doc     gltfDocument
+	fsys    fs.FS
+	dir     string
+	buffers [][]byte
+}
+
+func (d *gltfDecoder) loadBuffers(binChunk []byte) error {
+	d.buffers = make([][]byte, len(d.doc.Buffers))
+	for i, b := range d.doc.Buffers {
+		var data []byte
+		var err error
+		if b.URI == "" {
+			if i != 0 || binChunk == nil {
+				return fmt.Errorf("buffer %d has no uri", i)
+			}
+			data = binChunk
+		} else {
+			data, _, err = d.readURI(b.URI)
+			if err != nil {
+				return fmt.Errorf("buffer %d: %w", i, err)
+			}
+		}
+
+		if len(data) < b.ByteLength {
+			return fmt.Errorf("buffer %d is %d bytes but should be %d", i, len(data), b.ByteLength)
+		}
+		d.buffers[i] = data[:b.ByteLength]
+	}
+	return nil
+}
+
+// reads either a base64 data uri or a file relative to the gltf file
+func (d *gltfDecoder) readURI(uri string) (data []byte, mimeType string, err error) {
+	if strings.HasPrefix(uri, "data:") {
+		header, payload, ok := strings.Cut(uri[len("data:"):], ",")
+		if !ok {
+			return nil, "", errors.New("malformed data uri")
+		}
+		mimeType, isBase64 := strings.CutSuffix(header, ";base64")
+		if !isBase64 {
+			payload, err := url.PathUnescape(payload)
+			return []byte(payload), mimeType, err
+		}
+		data, err := base64.StdEncoding.DecodeString(payload)
+		return data, mimeType, err
+	}
+
+	path, err := url.PathUnescape(uri)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, ok := d.fsys.(glsl.OSFS); ok {
+		path = filepath.FromSlash(path)
+	}
+	data, err = fs.ReadFile(d.fsys, glsl.JoinPath(d.fsys, d.dir, path))
+	return data, "", err
+}
+
+func (d *gltfDecoder) bufferView(index int) ([]byte, int, error) {
+	if index < 0 || index >= len(d.doc.BufferViews) {
+		return nil, 0, fmt.Errorf("buffer view %d doesn't exist", index)
+	}
+	view := d.doc.BufferViews[index]
+	if view.Buffer < 0 || view.Buffer >= len(d.buffers) {
+		return nil, 0, fmt.Errorf("buffer view %d references missing buffer %d", index, view.Buffer)
+	}
+	buffer := d.buffers[view.Buffer]
+	if view.ByteOffset < 0 || view.ByteLength < 0 || view.ByteStride < 0 {
+		return nil, 0, fmt.Errorf("buffer view %d has a negative offset, length or stride", index)
+	}
+	//compared without adding them so huge values can't overflow
+	if view.ByteOffset > len(buffer) || view.ByteLength > len(buffer)-view.ByteOffset {
+		return nil, 0, fmt.Errorf("buffer view %d is out of range", index)
+	}
+	return buffer[view.ByteOffset : view.ByteOffset+view.ByteLength], view.ByteStride, nil
+}
+
+func (d *gltfDecoder) decodeTextures() ([]GLTFTexture, error) {
+	images := make([]image.Image, len(d.doc.Images))
+	for i, img := range d.doc.Images {
+		var data []byte
+		var err error
+		if img.BufferView != nil {
+			data, _, err = d.bufferView(*img.BufferView)
+		} else {
+			data, _, err = d.readURI(img.URI)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i, err)
+		}
+
+		images[i], _, err = image.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("image %d: %w", i, err)
+		}
+	}
+
+	textures := make([]GLTFTexture, len(d.doc.Textures))
+	for i, t := range d.doc.Textures {
+		if t.Source == nil || *t.Source < 0 || *t.Source >= len(images) {
+			return nil, fmt.Errorf("texture %d has no valid image", i)
+		}
+		textures[i].Image = images[*t.Source]
+
+		if t.Sampler != nil {
+			if *t.Sampler < 0 || *t.Sampler >= len(d.doc.Samplers) {
+				return nil, fmt.Errorf("texture %d references missing sampler %d", i, *t.Sampler)
+			}
+			s := d.doc.Samplers[*t.Sampler]
+			textures[i].MagFilter = s.MagFilter
+			textures[i].MinFilter = s.MinFilter
+			textures[i].WrapS = s.WrapS
+			textures[i].WrapT = s.WrapT
+		}
+	}
+	return textures, nil
+}
+
+func (d *gltfDecoder) decodeMaterials(textureCount int) ([]PBRMaterial, error) {
+	materials := make([]PBRMaterial, len(d.doc.Materials))
+	for i, m := range d.doc.Materials {
+		pbr := m.PBRMetallicRoughness
+		out := PBRMaterial{
+			Name:              m.Name,
+			BaseColorFactor:   mgl32.Vec4{1, 1, 1, 1},
+			MetallicFactor:    1,
+			RoughnessFactor:   1,
+			NormalScale:       1,
+			OcclusionStrength: 1,
+			AlphaMode:         "OPAQUE",
+			AlphaCutoff:       0.5,
+			DoubleSided:       m.DoubleSided,
+		}
+
+		if len(pbr.BaseColorFactor) == 4 {
+			out.BaseColorFactor = mgl32.Vec4(pbr.BaseColorFactor)
+		}
+		if pbr.MetallicFactor != nil {
+			out.MetallicFactor = *pbr.MetallicFactor
+		}
+		if pbr.RoughnessFactor != nil {
+			out.RoughnessFactor = *pbr.RoughnessFactor
+		}
+		if len(m.EmissiveFactor) == 3 {
+			out.EmissiveFactor = mgl32.Vec3(m.EmissiveFactor)
+		}
+		if m.AlphaMode != "" {
+			out.AlphaMode = m.AlphaMode
+		}
+		if m.AlphaCutoff != nil {
+			out.AlphaCutoff = *m.AlphaCutoff
+		}
+		if m.NormalTexture != nil && m.NormalTexture.Scale != nil {
+			out.NormalScale = *m.NormalTexture.Scale
+		}
+		if m.OcclusionTexture != nil && m.OcclusionTexture.Strength != nil {
+			out.OcclusionStrength = *m.OcclusionTexture.Strength
+		}
+
+		var err error
+		texture := func(info *gltfTextureInfo) int {
+			if info == nil {
+				return -1
+			}
+			if info.Index < 0 || info.Index >= textureCount {
+				err = fmt.Errorf("material %d references missing texture %d", i, info.Index)
+			}
+			return info.Index
+		}
+		out.BaseColorTexture = texture(pbr.BaseColorTexture)
+		out.MetallicRoughnessTexture = texture(pbr.MetallicRoughnessTexture)
+		out.NormalTexture = texture(m.NormalTexture)
+		out.OcclusionTexture = texture(m.OcclusionTexture)
+		out.EmissiveTexture = texture(m.EmissiveTexture)
+		if err != nil {
+			return nil, err
+		}
+
+		materials[i] = out
+	}
+	return materials, nil
+}
+
+func (d *gltfDecoder) decodeMeshes(materialCount int) ([]GLTFMesh, error) {
+	meshes := make([]GLTFMesh, len(d.doc.Meshes))
+	for i, m := range d.doc.Meshes {
+		meshes[i].Name = m.Name
+		for j, p := range m.Primitives {
+			mode := gl.TRIANGLES
+			if p.Mode != nil {
+				mode = *p.Mode
+			}
+			if mode != gl.TRIANGLES && mode != gl.TRIANGLE_STRIP && mode != gl.TRIANGLE_FAN {
+				continue //points and lines can't be drawn as an Object
+			}
+
+			prim, err := d.decodePrimitive(p.Attributes, p.Indices, mode)
+			if err != nil {
+				return nil, fmt.Errorf("mesh %d primitive %d: %w", i, j, err)
+			}
+
+			prim.Material = -1
+			if p.Material != nil {
+				if *p.Material < 0 || *p.Material >= materialCount {
+					return nil, fmt.Errorf("mesh %d primitive %d references missing material %d", i, j, *p.Material)
+				}
+				prim.Material = *p.Material
+			}
+
+			meshes[i].Primitives = append(meshes[i].Primitives, prim)
+		}
+	}
+	return meshes, nil
+}
+
+func (d *gltfDecoder) decodePrimitive(attributes map[string]int, indicesAccessor *int, mode int) (GLTFPrimitive, error) {
+	posIndex, ok := attributes["POSITION"]
+	if !ok {
+		return GLTFPrimitive{}, errors.New("primitive has no POSITION attribute")
+	}
+	positions, n, err := d.readAccessor(posIndex)
+	if err != nil {
+		return GLTFPrimitive{}, fmt.Errorf("POSITION: %w", err)
+	}
+	if n != 3 {
+		return GLTFPrimitive{}, fmt.Errorf("POSITION has %d components", n)
+	}
+	vertexCount := len(positions) / 3
+
+	var texCoords []float64
+	if index, ok := attributes["TEXCOORD_0"]; ok {
+		texCoords, n, err = d.readAccessor(index)
+		if err != nil {
+			return GLTFPrimitive{}, fmt.Errorf("TEXCOORD_0: %w", err)
+		}
+		if n != 2 || len(texCoords)/2 != vertexCount {
+			return GLTFPrimitive{}, errors.New("TEXCOORD_0 doesn't match POSITION")
+		}
+	}
+
+	p := GLTFPrimitive{
+		Verticies: make([]float32, 0, vertexCount*5),
+	}
+	for v := 0; v < vertexCount; v++ {
+		p.Verticies = append(p.Verticies, float32(positions[v*3]), float32(positions[v*3+1]), float32(positions[v*3+2]))
+		if texCoords != nil {
+			p.Verticies = append(p.Verticies, float32(texCoords[v*2]), float32(texCoords[v*2+1]))
+		} else {
+			p.Verticies = append(p.Verticies, 0, 0)
+		}
+	}
+
+	var indices []uint32
+	if indicesAccessor != nil {
+		values, n, err := d.readAccessor(*indicesAccessor)
+		if err != nil {
+			return GLTFPrimitive{}, fmt.Errorf("indices: %w", err)
+		}
+		if n != 1 {
+			return GLTFPrimitive{}, errors.New("indices accessor isn't SCALAR")
+		}
+		indices = make([]uint32, len(values))
+		for i, v := range values {
+			if int(v) >= vertexCount {
+				return GLTFPrimitive{}, fmt.Errorf("index %d is out of range", int(v))
+			}
+			indices[i] = uint32(v)
+		}
+	} else {
+		indices = make([]uint32, vertexCount)
+		for i := range indices {
+			indices[i] = uint32(i)
+		}
+	}
+	p.Indices = triangulate(indices, mode)
+
+	if index, ok := attributes["NORMAL"]; ok {
+		normals, n, err := d.readAccessor(index)
+		if err != nil {
+			return GLTFPrimitive{}, fmt.Errorf("NORMAL: %w", err)
+		}
+		if n != 3 || len(normals)/3 != vertexCount {
+			return GLTFPrimitive{}, errors.New("NORMAL doesn't match POSITION")
+		}
+		p.Normals = make([]float32, len(normals))
+		for i, v := range normals {
+			p.Normals[i] = float32(v)
+		}
+	} else {
+		o := Object{
+			Verticies:    p.Verticies,
+			VertexStride: 5,
+			Indices:      p.Indices,
+		}
+		o.CalcNormals(len(o.Indices) / 3)
+		p.Normals = o.normals
+	}
+
+	return p, nil
+}
+
+// converts strips and fans into a plain triangle list
+func triangulate(indices []uint32, mode int) []uint32 {
+	switch mode {
+	case gl.TRIANGLE_STRIP:
+		var out []uint32
+		for i := 0; i+2 < len(indices); i++ {
+			//every other triangle is flipped to keep the winding consistent
+			if i%2 == 0 {
+				out = append(out, indices[i], indices[i+1], indices[i+2])
+			} else {
+				out = append(out, indices[i+1], indices[i], indices[i+2])
+			}
+		}
+		return out
+	case gl.TRIANGLE_FAN:
+		var out []uint32
+		for i := 1; i+1 < len(indices); i++ {
+			out = append(out, indices[0], indices[i], indices[i+1])
+		}
+		return out
+	default:
+		return indices[:len(indices)/3*3]
+	}
+}
+
+func (d *gltfDecoder) decodeNodes(meshCount int) (nodes, roots []*SceneNode, err error) {
+	nodes = make([]*SceneNode, len(d.doc.Nodes))
+	for i, n := range d.doc.Nodes {
+		nodes[i] = &SceneNode{
+			Name:  n.Name,
+			Mesh:  -1,
+			Local: nodeTransform(n.Matrix, n.Translation, n.Rotation, n.Scale),
+		}
+		if n.Mesh != nil {
+			if *n.Mesh < 0 || *n.Mesh >= meshCount {
+				return nil, nil, fmt.Errorf("node %d references missing mesh %d", i, *n.Mesh)
+			}
+			nodes[i].Mesh = *n.Mesh
+		}
+	}
+
+	for i, n := range d.doc.Nodes {
+		for _, c := range n.Children {
+			if c < 0 || c >= len(nodes) {
+				return nil, nil, fmt.Errorf("node %d references missing child %d", i, c)
+			}
+			if nodes[c].Parent != nil || c == i {
+				return nil, nil, fmt.Errorf("node %d has more than one parent", c)
+			}
+			nodes[c].Parent = nodes[i]
+			nodes[i].Children = append(nodes[i].Children, nodes[c])
+		}
+	}
+
+	if len(d.doc.Scenes) > 0 {
+		scene := 0
+		if d.doc.Scene != nil {
+			scene = *d.doc.Scene
+		}
+		if scene < 0 || scene >= len(d.doc.Scenes) {
+			return nil, nil, fmt.Errorf("scene %d doesn't exist", scene)
+		}
+		for _, n := range d.doc.Scenes[scene].Nodes {
+			if n < 0 || n >= len(nodes) {
+				return nil, nil, fmt.Errorf("scene references missing node %d", n)
+			}
+			roots = append(roots, nodes[n])
+		}
+	} else {
+		for _, n := range nodes {
+			if n.Parent == nil {
+				roots = append(roots, n)
+			}
+		}
+	}
+
+	for _, root := range roots {
+		if root.Parent != nil {
+			return nil, nil, fmt.Errorf("scene root %q has a parent", root.Name)
+		}
+		root.updateWorld(mgl32.Ident4())
+	}
+	return nodes, roots, nil
+}
+
+func nodeTransform(matrix, translation, rotation, scale []float32) mgl32.Mat4 {
+	if len(matrix) == 16 {
+		//both glTF and mgl32 store matrices column major
+		return mgl32.Mat4(matrix)
+	}
+
+	t := mgl32.Ident4()
+	if len(translation) == 3 {
+		t = mgl32.Translate3D(translation[0], translation[1], translation[2])
+	}
+	r := mgl32.Ident4()
+	if len(rotation) == 4 {
+		//glTF quaternions are XYZW
+		r = mgl32.Quat{W: rotation[3], V: mgl32.Vec3{rotation[0], rotation[1], rotation[2]}}.Normalize().Mat4()
+	}
+	s := mgl32.Ident4()
+	if len(scale) == 3 {
+		s = mgl32.Scale3D(scale[0], scale[1], scale[2])
+	}
+	return t.Mul4(r).Mul4(s)
+}
+
+func gltfComponentCount(accessorType string) (count, columns int, err error) {
+	switch accessorType {
+	case "SCALAR":
+		return 1, 1, nil
+	case "VEC2":
+		return 2, 1, nil
+	case "VEC3":
+		return 3, 1, nil
+	case "VEC4":
+		return 4, 1, nil
+	case "MAT2":
+		return 4, 2, nil
+	case "MAT3":
+		return 9, 3, nil
+	case "MAT4":
+		return 16, 4, nil
+	}
+	return 0, 0, fmt.Errorf("unknown accessor type %q", accessorType)
+}
+
+func gltfComponentSize(componentType int) (int, error) {
+	switch componentType {
+	case gltfByte, gltfUnsignedByte:
+		return 1, nil
+	case gltfShort, gltfUnsignedShort:
+		return 2, nil
+	case gltfUnsignedInt, gltfFloat:
+		return 4, nil
+	}
+	return 0, fmt.Errorf("unknown component type %d", componentType)
+}
+
+// reads a single component, normalized integers are mapped
+// to 0..1 or -1..1 the same way the gpu would
+func readGLTFComponent(data []byte, componentType int, normalized bool) float64 {
+	switch componentType {
+	case gltfByte:
+		v := float64(int8(data[0]))
+		if normalized {
+			return math.Max(v/127, -1)
+		}
+		return v
+	case gltfUnsignedByte:
+		v := float64(data[0])
+		if normalized {
+			return v / 255
+		}
+		return v
+	case gltfShort:
+		v := float64(int16(binary.LittleEndian.Uint16(data)))
+		if normalized {
+			return math.Max(v/32767, -1)
+		}
+		return v
+	case gltfUnsignedShort:
+		v := float64(binary.LittleEndian.Uint16(data))
+		if normalized {
+			return v / 65535
+		}
+		return v
+	case gltfUnsignedInt:
+		return float64(binary.LittleEndian.Uint32(data))
+	default:
+		return float64(math.Float32frombits(binary.LittleEndian.Uint32(data)))
+	}
+}
+
+// reads every element of an accessor into a flat slice
+// along with the number of components in each element
+func (d *gltfDecoder) readAccessor(index int) ([]float64, int, error) {
+	if index < 0 || index >= len(d.doc.Accessors) {
+		return nil, 0, fmt.Errorf("accessor %d doesn't exist", index)
+	}
+	a := d.doc.Accessors[index]
+
+	count, columns, err := gltfComponentCount(a.Type)
+	if err != nil {
+		return nil, 0, err
+	}
+	componentSize, err := gltfComponentSize(a.ComponentType)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	//without a buffer view nothing else limits the count
+	if a.Count < 0 || a.Count > math.MaxInt32/count {
+		return nil, 0, fmt.Errorf("accessor %d has an invalid count %d", index, a.Count)
+	}
+
+	var data []byte
+	var stride int
+	if a.BufferView != nil {
+		view, viewStride, err := d.bufferView(*a.BufferView)
+		if err != nil {
+			return nil, 0, err
+		}
+		data, stride, err = gltfElements(view, a.ByteOffset, viewStride, a.Count, count, columns, componentSize)
+		if err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", index, err)
+		}
+	}
+
+	values := make([]float64, a.Count*count)
+	if a.BufferView != nil {
+		readGLTFElements(values, data, stride, a.Count, count, columns, componentSize, a.ComponentType, a.Normalized)
+	}
+	// without a buffer view the accessor is all zeros unless sparse sets some
+
+	if a.Sparse != nil {
+		if err := d.applySparse(a, values, count, columns, componentSize); err != nil {
+			return nil, 0, fmt.Errorf("accessor %d: %w", index, err)
+		}
+	}
+
+	return values, count, nil
+}
+
+// matrix columns are padded to 4 byte boundaries
+func gltfColumnSize(count, columns, componentSize int) int {
+	rows := count / columns
+	if columns == 1 {
+		return rows * componentSize
+	}
+	return (rows*componentSize + 3) &^ 3
+}
+
+// the data of elementCount elements starting byteOffset into a buffer view
+// and the stride between them, which is the element size when stride is 0
+func gltfElements(view []byte, byteOffset, stride, elementCount, count, columns, componentSize int) ([]byte, int, error) {
+	if byteOffset < 0 || byteOffset > len(view) {
+		return nil, 0, fmt.Errorf("byte offset %d is out of range of its buffer view", byteOffset)
+	}
+	data := view[byteOffset:]
+
+	elementSize := gltfColumnSize(count, columns, componentSize) * columns
+	if stride == 0 {
+		stride = elementSize
+	}
+	//the same as (elementCount-1)*stride+elementSize > len(data) without overflowing
+	if elementCount > 0 && (elementSize > len(data) || elementCount-1 > (len(data)-elementSize)/stride) {
+		return nil, 0, errors.New("data is out of range of its buffer view")
+	}
+	return data, stride, nil
+}
+
+// each column is read separately as they're padded, the
+// elements have to have been checked with gltfElements
+func readGLTFElements(values []float64, data []byte, stride, elementCount, count, columns, componentSize, componentType int, normalized bool) {
+	rows := count / columns
+	columnSize := gltfColumnSize(count, columns, componentSize)
+
+	for e := 0; e < elementCount; e++ {
+		for c := 0; c < columns; c++ {
+			for r := 0; r < rows; r++ {
+				offset := e*stride + c*columnSize + r*componentSize
+				values[e*count+c*rows+r] = readGLTFComponent(data[offset:], componentType, normalized)
+			}
+		}
+	}
+}
+
+func (d *gltfDecoder) applySparse(a gltfAccessor, values []float64, count, columns, componentSize int) error {
+	s := a.Sparse
+	if s.Count < 0 {
+		return errors.New("sparse accessor has a negative count")
+	}
+
+	indexView, _, err := d.bufferView(s.Indices.BufferView)
+	if err != nil {
+		return err
+	}
+	indexSize, err := gltfComponentSize(s.Indices.ComponentType)
+	if err != nil {
+		return err
+	}
+	indexData, indexStride, err := gltfElements(indexView, s.Indices.ByteOffset, 0, s.Count, 1, 1, indexSize)
+	if err != nil {
+		return fmt.Errorf("sparse indices: %w", err)
+	}
+
+	valueView, _, err := d.bufferView(s.Values.BufferView)
+	if err != nil {
+		return err
+	}
+	valueData, valueStride, err := gltfElements(valueView, s.Values.ByteOffset, 0, s.Count, count, columns, componentSize)
+	if err != nil {
+		return fmt.Errorf("sparse values: %w", err)
+	}
+
+	indices := make([]float64, s.Count)
+	readGLTFElements(indices, indexData, indexStride, s.Count, 1, 1, indexSize, s.Indices.ComponentType, false)
+	sparseValues := make([]float64, s.Count*count)
+	readGLTFElements(sparseValues, valueData, valueStride, s.Count, count, columns, componentSize, a.ComponentType, a.Normalized)
+
+	for i, index := range indices {
+		if int(index) >= a.Count {
+			return fmt.Errorf("sparse index %d is out of range", int(index))
+		}
+		copy(values[int(index)*count:(int(index)+1)*count], sparseValues[i*count:(i+1)*count])
+	}
+	return nil
+}
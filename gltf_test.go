@@ -0,0 +1,337 @@
+package gogl
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// a buffer with a quad's positions, the indices of a triangle and of the
+// quad and the quad's uvs as normalized bytes in a strided buffer view
+func testGLTFBuffer() string {
+	var buf []byte
+	for _, v := range []float32{0, 0, 0, 1, 0, 0, 1, 1, 0, 0, 1, 0} {
+		buf = binary.LittleEndian.AppendUint32(buf, math.Float32bits(v))
+	}
+	for _, i := range []uint16{0, 1, 2, 0, 0, 1, 2, 0, 2, 3} { //the triangle is padded to 4 bytes
+		buf = binary.LittleEndian.AppendUint16(buf, i)
+	}
+	for _, uv := range [][2]byte{{0, 0}, {255, 0}, {255, 255}, {0, 255}} {
+		buf = append(buf, uv[0], uv[1], 0, 0)
+	}
+	return "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(buf)
+}
+
+// a file with two scenes, the first has a node with a child
+// and the second a single node that isn't in the first
+func testGLTF(t *testing.T, edit func(doc map[string]any)) []byte {
+	t.Helper()
+	doc := map[string]any{
+		"asset":   map[string]any{"version": "2.0"},
+		"buffers": []any{map[string]any{"uri": testGLTFBuffer(), "byteLength": 84}},
+		"bufferViews": []any{
+			map[string]any{"buffer": 0, "byteOffset": 0, "byteLength": 48},
+			map[string]any{"buffer": 0, "byteOffset": 48, "byteLength": 20},
+			map[string]any{"buffer": 0, "byteOffset": 68, "byteLength": 16, "byteStride": 4},
+		},
+		"accessors": []any{
+			map[string]any{"bufferView": 0, "componentType": gltfFloat, "count": 4, "type": "VEC3"},
+			map[string]any{"bufferView": 1, "componentType": gltfUnsignedShort, "count": 3, "type": "SCALAR"},
+			map[string]any{"bufferView": 1, "byteOffset": 8, "componentType": gltfUnsignedShort, "count": 6, "type": "SCALAR"},
+			map[string]any{"bufferView": 2, "componentType": gltfUnsignedByte, "normalized": true, "count": 4, "type": "VEC2"},
+		},
+		"meshes": []any{
+			map[string]any{"name": "triangle", "primitives": []any{
+				map[string]any{"attributes": map[string]any{"POSITION": 0}, "indices": 1},
+			}},
+			map[string]any{"name": "quad", "primitives": []any{
+				map[string]any{"attributes": map[string]any{"POSITION": 0, "TEXCOORD_0": 3}, "indices": 2},
+			}},
+		},
+		"nodes": []any{
+			map[string]any{"name": "parent", "mesh": 0, "translation": []float32{1, 0, 0}, "children": []int{1}},
+			map[string]any{"name": "child", "mesh": 1, "translation": []float32{0, 2, 0}, "scale": []float32{2, 2, 2}},
+			map[string]any{"name": "other", "mesh": 0},
+		},
+		"scenes": []any{
+			map[string]any{"nodes": []int{0}},
+			map[string]any{"nodes": []int{2}},
+		},
+		"scene": 0,
+	}
+	if edit != nil {
+		edit(doc)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestDecodeGLTFHierarchy(t *testing.T) {
+	data, err := DecodeGLTF(testGLTF(t, nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(data.Nodes) != 3 || len(data.Roots) != 1 {
+		t.Fatalf("got %d nodes and %d roots, want 3 and 1", len(data.Nodes), len(data.Roots))
+	}
+	parent, child := data.Roots[0], data.Nodes[1]
+	if parent.Name != "parent" || parent.Parent != nil {
+		t.Errorf("root is %q with parent %v", parent.Name, parent.Parent)
+	}
+	if child.Parent != parent || len(parent.Children) != 1 || parent.Children[0] != child {
+		t.Error("child isn't linked to its parent")
+	}
+	if child.Mesh != 1 || data.Nodes[2].Mesh != 0 {
+		t.Errorf("meshes are %d and %d", child.Mesh, data.Nodes[2].Mesh)
+	}
+
+	want := mgl32.Translate3D(1, 0, 0).Mul4(mgl32.Translate3D(0, 2, 0)).Mul4(mgl32.Scale3D(2, 2, 2))
+	if !child.World.ApproxEqual(want) {
+		t.Errorf("child world matrix is\n%v\nwant\n%v", child.World, want)
+	}
+	if !parent.World.ApproxEqual(mgl32.Translate3D(1, 0, 0)) {
+		t.Errorf("parent world matrix is\n%v", parent.World)
+	}
+
+	child.Local = mgl32.Ident4()
+	(&Scene{Roots: data.Roots}).UpdateTransforms()
+	if !child.World.ApproxEqual(mgl32.Translate3D(1, 0, 0)) {
+		t.Errorf("UpdateTransforms left the child at\n%v", child.World)
+	}
+}
+
+func TestDecodeGLTFAccessors(t *testing.T) {
+	data, err := DecodeGLTF(testGLTF(t, nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	triangle := data.Meshes[0].Primitives[0]
+	if !slices.Equal(triangle.Indices, []uint32{0, 1, 2}) {
+		t.Errorf("triangle indices = %v", triangle.Indices)
+	}
+	if triangle.Material != -1 {
+		t.Errorf("triangle material = %d, want -1", triangle.Material)
+	}
+	//positions with zeroed uvs as there's no TEXCOORD_0
+	if !slices.Equal(triangle.Verticies[5:10], []float32{1, 0, 0, 0, 0}) {
+		t.Errorf("triangle vertex 1 = %v", triangle.Verticies[5:10])
+	}
+	if !slices.Equal(triangle.Normals[:3], []float32{0, 0, 1}) {
+		t.Errorf("calculated normal = %v", triangle.Normals[:3])
+	}
+
+	quad := data.Meshes[1].Primitives[0]
+	if !slices.Equal(quad.Indices, []uint32{0, 1, 2, 0, 2, 3}) {
+		t.Errorf("quad indices read from a byte offset = %v", quad.Indices)
+	}
+	wantVertices := []float32{
+		0, 0, 0, 0, 0,
+		1, 0, 0, 1, 0,
+		1, 1, 0, 1, 1,
+		0, 1, 0, 0, 1,
+	}
+	if !slices.Equal(quad.Verticies, wantVertices) {
+		t.Errorf("quad vertices with strided normalized uvs = %v", quad.Verticies)
+	}
+}
+
+func TestDecodeGLTFScenes(t *testing.T) {
+	data, err := DecodeGLTF(testGLTF(t, func(doc map[string]any) { doc["scene"] = 1 }), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data.Roots) != 1 || data.Roots[0].Name != "other" {
+		t.Fatalf("roots of scene 1 = %v", data.Roots)
+	}
+
+	//without scenes every node without a parent is a root
+	data, err = DecodeGLTF(testGLTF(t, func(doc map[string]any) {
+		delete(doc, "scenes")
+		delete(doc, "scene")
+	}), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, root := range data.Roots {
+		names = append(names, root.Name)
+	}
+	if !slices.Equal(names, []string{"parent", "other"}) {
+		t.Errorf("roots without scenes = %v", names)
+	}
+}
+
+func testAccessor(doc map[string]any, index int) map[string]any {
+	return doc["accessors"].([]any)[index].(map[string]any)
+}
+
+// replaces count elements starting at index 0 with the first vertex
+func testSparse(count, indexOffset int) map[string]any {
+	return map[string]any{
+		"count":   count,
+		"indices": map[string]any{"bufferView": 1, "byteOffset": indexOffset, "componentType": gltfUnsignedShort},
+		"values":  map[string]any{"bufferView": 0},
+	}
+}
+
+func TestDecodeGLTFErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		edit      func(doc map[string]any)
+		wantError string
+	}{
+		{
+			name:      "missing scene",
+			edit:      func(doc map[string]any) { doc["scene"] = 2 },
+			wantError: "scene 2 doesn't exist",
+		},
+		{
+			name: "two parents",
+			edit: func(doc map[string]any) {
+				doc["nodes"].([]any)[2].(map[string]any)["children"] = []int{1}
+			},
+			wantError: "more than one parent",
+		},
+		{
+			name: "child as a scene root",
+			edit: func(doc map[string]any) {
+				doc["scenes"].([]any)[0].(map[string]any)["nodes"] = []int{0, 1}
+			},
+			wantError: "has a parent",
+		},
+		{
+			name:      "missing mesh",
+			edit:      func(doc map[string]any) { doc["nodes"].([]any)[2].(map[string]any)["mesh"] = 5 },
+			wantError: "missing mesh 5",
+		},
+		{
+			name: "accessor past its buffer view",
+			edit: func(doc map[string]any) {
+				doc["accessors"].([]any)[2].(map[string]any)["count"] = 7
+			},
+			wantError: "out of range",
+		},
+		{
+			name: "index past the vertices",
+			edit: func(doc map[string]any) {
+				doc["accessors"].([]any)[0].(map[string]any)["count"] = 2
+			},
+			wantError: "index 2 is out of range",
+		},
+		{
+			name:      "negative count",
+			edit:      func(doc map[string]any) { testAccessor(doc, 0)["count"] = -1 },
+			wantError: "accessor 0 has an invalid count -1",
+		},
+		{
+			name:      "overflowing count",
+			edit:      func(doc map[string]any) { testAccessor(doc, 0)["count"] = math.MaxInt64 / 2 },
+			wantError: "invalid count",
+		},
+		{
+			name:      "negative accessor offset",
+			edit:      func(doc map[string]any) { testAccessor(doc, 2)["byteOffset"] = -2 },
+			wantError: "byte offset -2 is out of range",
+		},
+		{
+			name:      "accessor offset past its buffer view",
+			edit:      func(doc map[string]any) { testAccessor(doc, 2)["byteOffset"] = 100 },
+			wantError: "byte offset 100 is out of range",
+		},
+		{
+			name: "negative buffer view offset",
+			edit: func(doc map[string]any) {
+				doc["bufferViews"].([]any)[1].(map[string]any)["byteOffset"] = -4
+			},
+			wantError: "buffer view 1 has a negative offset",
+		},
+		{
+			name: "negative buffer view stride",
+			edit: func(doc map[string]any) {
+				doc["bufferViews"].([]any)[2].(map[string]any)["byteStride"] = -4
+			},
+			wantError: "buffer view 2 has a negative offset, length or stride",
+		},
+		{
+			name: "overflowing buffer view",
+			edit: func(doc map[string]any) {
+				view := doc["bufferViews"].([]any)[1].(map[string]any)
+				view["byteLength"] = math.MaxInt64 - 10
+			},
+			wantError: "buffer view 1 is out of range",
+		},
+		{
+			name: "negative sparse count",
+			edit: func(doc map[string]any) {
+				testAccessor(doc, 0)["sparse"] = testSparse(-1, 0)
+			},
+			wantError: "sparse accessor has a negative count",
+		},
+		{
+			name: "negative sparse offset",
+			edit: func(doc map[string]any) {
+				testAccessor(doc, 0)["sparse"] = testSparse(1, -2)
+			},
+			wantError: "sparse indices: byte offset -2 is out of range",
+		},
+		{
+			name:      "required extension",
+			edit:      func(doc map[string]any) { doc["extensionsRequired"] = []string{"KHR_draco_mesh_compression"} },
+			wantError: "unsupported required extensions",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := DecodeGLTF(testGLTF(t, test.edit), "")
+			if err == nil || !strings.Contains(err.Error(), test.wantError) {
+				t.Errorf("got error %v, want one containing %q", err, test.wantError)
+			}
+		})
+	}
+}
+
+func TestSceneDrawOnlyDrawsRoots(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	defer restore()
+
+	data, err := DecodeGLTF(testGLTF(t, nil), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	scene := data.Upload()
+	defer scene.Delete()
+
+	shader, err := NewEmbeddedShaderErr(testVertexShader, testFragmentShader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shader.Delete()
+	shader.Use()
+
+	fake.Reset()
+	scene.Draw(shader)
+
+	var counts []int32
+	for _, call := range fake.CallsTo("DrawElementsWithOffset") {
+		counts = append(counts, call.Args[1].(int32))
+	}
+	//the triangle of the root then the quad of its child, not the node in the other scene
+	if !slices.Equal(counts, []int32{3, 6}) {
+		t.Errorf("drew index counts %v, want [3 6]", counts)
+	}
+	if len(fake.Errors) > 0 {
+		t.Errorf("gl errors: %v", fake.Errors)
+	}
+}
@@ -6,7 +6,7 @@ import (
 )
 
 func GetVersion() string {
-	return gl.GoStr(gl.GetString(gl.VERSION))
+	return gl.GoStr(ogl.GetString(gl.VERSION))
 }
 
 func TriangleNormal(p1, p2, p3 mgl32.Vec3) mgl32.Vec3 {
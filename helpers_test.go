@@ -0,0 +1,85 @@
+package gogl
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+const (
+	testVertexShader   = "#version 330 core\nlayout (location = 0) in vec3 aPos;\nuniform mat4 model;\nvoid main() { gl_Position = model * vec4(aPos, 1); }"
+	testFragmentShader = "#version 330 core\nout vec4 color;\nvoid main() { color = vec4(1); }"
+)
+
+// a vertex array with aPos and aUV at locations 0 and 1 and
+// a third unnamed attribute at 2 when withExtra is set
+func testBufferLoader(t *testing.T, withExtra bool) (*RecordingGL, BufferID, *BufferLoader) {
+	t.Helper()
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	vao := GenBindVertexArray()
+	GenBindBuffer(gl.ARRAY_BUFFER)
+	loader := NewBufferLoader()
+	if withExtra {
+		loader.BuildFloatBuffer(vao, NewBufferLayout([]int32{3, 2, 3}, make([]float32, 8*3)).Named("aPos", "aUV"))
+	} else {
+		loader.BuildFloatBuffer(vao, NewBufferLayout([]int32{3, 2}, make([]float32, 5*3)).Named("aPos", "aUV"))
+	}
+	return fake, vao, loader
+}
+
+func testShader(t *testing.T, vertexShader string) *EmbeddedShader {
+	t.Helper()
+	shader, err := NewEmbeddedShaderErr(vertexShader, testFragmentShader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+	return shader
+}
+
+func testTriangle(t *testing.T) *Object {
+	t.Helper()
+	o := &Object{
+		Verticies:    []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1},
+		VertexStride: 5,
+		Indices:      []uint32{0, 1, 2},
+	}
+	o.CalcNormals(1)
+	o.FillBuffers()
+	t.Cleanup(o.Delete)
+	return o
+}
+
+func floatBytes(values []float32) []byte {
+	data := make([]byte, 0, 4*len(values))
+	for _, v := range values {
+		data = binary.LittleEndian.AppendUint32(data, math.Float32bits(v))
+	}
+	return data
+}
+
+// a cache that claims binaries are supported
+// so the fake's failures reach the error callback
+func testProgramCache(t *testing.T) (cache *ProgramCache, reported *[]error) {
+	t.Helper()
+	cache = &ProgramCache{dir: t.TempDir(), driver: "test", supported: true}
+	reported = new([]error)
+	cache.SetErrorCallback(func(err error) {
+		*reported = append(*reported, err)
+	})
+
+	SetProgramCache(cache)
+	t.Cleanup(func() { SetProgramCache(nil) })
+	return cache, reported
+}
+
+func testStages() []StageSource {
+	return []StageSource{
+		{Stage: gl.VERTEX_SHADER, Source: &ShaderSource{Source: testVertexShader}},
+		{Stage: gl.FRAGMENT_SHADER, Source: &ShaderSource{Source: testFragmentShader}},
+	}
+}
@@ -0,0 +1,116 @@
+package gogl
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// the kinds of gl object the leak tracker knows about
+const (
+	ResourceBuffer      = "buffer"
+	ResourceVertexArray = "vertex array"
+	ResourceTexture     = "texture"
+	ResourceProgram     = "program"
+	ResourceShader      = "shader"
+	ResourceFramebuffer = "framebuffer"
+	// the color and depth storage of a Framebuffer
+	ResourceRenderbuffer = "renderbuffer"
+)
+
+// a gl object that was created but never deleted
+type Leak struct {
+	Kind  string
+	ID    uint32
+	Stack string // where it was created
+}
+
+type resourceKey struct {
+	kind string
+	id   uint32
+}
+
+var leakTracker struct {
+	mu      sync.Mutex
+	enabled bool
+	alive   map[resourceKey]string
+}
+
+// starts recording where every buffer, vertex array, texture, program
+// and shader is created so the ones never deleted can be reported with
+// ReportLeaks. Only objects created after this are tracked and recording
+// the stacks is slow so it's meant for debugging
+func EnableLeakTracking() {
+	leakTracker.mu.Lock()
+	defer leakTracker.mu.Unlock()
+
+	leakTracker.enabled = true
+	if leakTracker.alive == nil {
+		leakTracker.alive = make(map[resourceKey]string)
+	}
+}
+
+func trackResource(kind string, id uint32) {
+	leakTracker.mu.Lock()
+	defer leakTracker.mu.Unlock()
+
+	if !leakTracker.enabled || id == 0 {
+		return
+	}
+	leakTracker.alive[resourceKey{kind, id}] = callerStack()
+}
+
+func untrackResource(kind string, id uint32) {
+	leakTracker.mu.Lock()
+	defer leakTracker.mu.Unlock()
+
+	delete(leakTracker.alive, resourceKey{kind, id})
+}
+
+// the stack of whoever created the resource, without the tracker itself
+func callerStack() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var stack strings.Builder
+	for {
+		frame, more := frames.Next()
+		fmt.Fprintf(&stack, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+		if !more {
+			break
+		}
+	}
+	return stack.String()
+}
+
+// every tracked resource that hasn't been deleted yet
+func Leaks() []Leak {
+	leakTracker.mu.Lock()
+	defer leakTracker.mu.Unlock()
+
+	leaks := make([]Leak, 0, len(leakTracker.alive))
+	for key, stack := range leakTracker.alive {
+		leaks = append(leaks, Leak{key.kind, key.id, stack})
+	}
+	sort.Slice(leaks, func(i, j int) bool {
+		if leaks[i].Kind != leaks[j].Kind {
+			return leaks[i].Kind < leaks[j].Kind
+		}
+		return leaks[i].ID < leaks[j].ID
+	})
+	return leaks
+}
+
+// writes every leaked resource and where it was created to w and
+// returns how many there were, call it at shutdown after cleaning up
+func ReportLeaks(w io.Writer) int {
+	leaks := Leaks()
+	for _, leak := range leaks {
+		fmt.Fprintf(w, "leaked %s %d created at:\n%s\n", leak.Kind, leak.ID, leak.Stack)
+	}
+	return len(leaks)
+}
@@ -0,0 +1,71 @@
+package gogl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// enables leak tracking for the rest of the test and
+// forgets everything it tracked once the test is done
+func testLeakTracking(t *testing.T) {
+	t.Helper()
+	EnableLeakTracking()
+	t.Cleanup(func() {
+		leakTracker.mu.Lock()
+		defer leakTracker.mu.Unlock()
+		leakTracker.enabled = false
+		leakTracker.alive = nil
+	})
+}
+
+func TestLeaks(t *testing.T) {
+	_, restore := UseRecordingGL()
+	defer restore()
+	testLeakTracking(t)
+
+	vao := GenBindVertexArray()
+	buffer := GenBindBuffer(gl.ARRAY_BUFFER)
+	texture := GenBindTexture()
+	shader, err := NewEmbeddedShaderErr(testVertexShader, testFragmentShader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//the shaders are deleted once the program is linked
+	want := []Leak{
+		{Kind: ResourceBuffer, ID: uint32(buffer)},
+		{Kind: ResourceProgram, ID: uint32(shader.id)},
+		{Kind: ResourceTexture, ID: uint32(texture)},
+		{Kind: ResourceVertexArray, ID: uint32(vao)},
+	}
+	leaks := Leaks()
+	if len(leaks) != len(want) {
+		t.Fatalf("leaks = %v, want %v", leaks, want)
+	}
+	for i, leak := range leaks {
+		if leak.Kind != want[i].Kind || leak.ID != want[i].ID {
+			t.Errorf("leak %d is %s %d, want %s %d", i, leak.Kind, leak.ID, want[i].Kind, want[i].ID)
+		}
+		if !strings.Contains(leak.Stack, "TestLeaks") || strings.Contains(leak.Stack, "trackResource") {
+			t.Errorf("the %s was created at\n%s", leak.Kind, leak.Stack)
+		}
+	}
+
+	var report bytes.Buffer
+	if n := ReportLeaks(&report); n != len(want) || !strings.Contains(report.String(), "leaked texture") {
+		t.Errorf("reported %d leaks:\n%s", n, report.String())
+	}
+
+	vao.DeleteVertexArray()
+	buffer.Delete()
+	texture.Delete()
+	shader.Delete()
+
+	report.Reset()
+	if n := ReportLeaks(&report); n != 0 || report.Len() != 0 {
+		t.Errorf("reported %d leaks after deleting everything:\n%s", n, report.String())
+	}
+}
@@ -0,0 +1,554 @@
+package gogl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+// a material read from a wavefront .mtl file
+// map paths are relative to the .mtl file unless loaded with LoadObj
+type Material struct {
+	Name string
+
+	Ambient   mgl32.Vec3 // Ka
+	Diffuse   mgl32.Vec3 // Kd
+	Specular  mgl32.Vec3 // Ks
+	Emissive  mgl32.Vec3 // Ke
+	Shininess float32    // Ns
+	Opacity   float32    // d or 1-Tr
+	IOR       float32    // Ni
+	Illum     int
+
+	AmbientMap  string
+	DiffuseMap  string
+	SpecularMap string
+	NormalMap   string
+	AlphaMap    string
+}
+
+// a single drawable part of an .obj file
+// a new mesh is started whenever the object, group or material changes
+type ObjMesh struct {
+	Name     string
+	Group    string
+	Material *Material
+
+	Verticies []float32 //in XYZ UV
+	Normals   []float32
+	Indices   []uint32
+}
+
+type ObjModel struct {
+	Meshes       []ObjMesh
+	Materials    map[string]*Material
+	MaterialLibs []string
+	// materials used with usemtl that aren't in any of the MaterialLibs,
+	// the meshes using them get a DefaultMaterial with the same name
+	MissingMaterials []string
+	// the MaterialLibs that don't exist, their materials are missing too
+	MissingMaterialLibs []string
+}
+
+// the material of meshes whose usemtl names a material that isn't in any
+// .mtl file, and of every mesh with a usemtl when parsed with ParseObj.
+// It's the plain grey most exporters use
+func DefaultMaterial(name string) *Material {
+	return &Material{
+		Name:    name,
+		Ambient: mgl32.Vec3{0.2, 0.2, 0.2},
+		Diffuse: mgl32.Vec3{0.8, 0.8, 0.8},
+		Opacity: 1,
+		IOR:     1,
+		Illum:   1,
+	}
+}
+
+// reads an .obj file and every .mtl file it references
+// .mtl files that don't exist are skipped like missing materials
+func LoadObj(path string) (*ObjModel, error) {
+	return LoadObjFS(glsl.OSFS{}, path)
+}
+
+// same as LoadObj but reads the files from fsys
+// the texture map paths of the materials are also paths in fsys
+func LoadObjFS(fsys fs.FS, path string) (*ObjModel, error) {
+	file, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	model, err := ParseObj(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	dir := glsl.DirPath(fsys, path)
+	for _, lib := range model.MaterialLibs {
+		mtlPath := glsl.JoinPath(fsys, dir, lib)
+		mtlFile, err := fsys.Open(mtlPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			model.MissingMaterialLibs = append(model.MissingMaterialLibs, lib)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		materials, err := ParseMtl(mtlFile)
+		mtlFile.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", mtlPath, err)
+		}
+
+		mtlDir := glsl.DirPath(fsys, mtlPath)
+		for name, m := range materials {
+			if err := m.resolveMaps(fsys, mtlDir); err != nil {
+				return nil, fmt.Errorf("%s: %w", mtlPath, err)
+			}
+			model.Materials[name] = m
+		}
+	}
+
+	missing := make(map[string]bool)
+	for i := range model.Meshes {
+		name := model.Meshes[i].materialName()
+		if name == "" {
+			continue
+		}
+		if mat, ok := model.Materials[name]; ok {
+			model.Meshes[i].Material = mat
+		} else if !missing[name] {
+			missing[name] = true
+			model.MissingMaterials = append(model.MissingMaterials, name)
+		}
+	}
+
+	return model, nil
+}
+
+// uploads every mesh in the model to the gpu
+func (m *ObjModel) Objects() []Object {
+	objects := make([]Object, len(m.Meshes))
+	for i, mesh := range m.Meshes {
+		objects[i] = mesh.Object()
+	}
+	return objects
+}
+
+// uploads the mesh to the gpu as an indexed Object
+func (m ObjMesh) Object() Object {
+	o := Object{
+		Type:         m.Name,
+		Verticies:    m.Verticies,
+		VertexStride: 5,
+		Indices:      m.Indices,
+		normals:      m.Normals,
+	}
+	o.FillBuffers()
+
+	return o
+}
+
+// materials are only referenced by name until the .mtl
+// files are loaded so the name is kept on a placeholder
+func (m ObjMesh) materialName() string {
+	if m.Material == nil {
+		return ""
+	}
+	return m.Material.Name
+}
+
+type objVertexKey struct {
+	v, vt, vn int
+}
+
+type objParser struct {
+	positions []mgl32.Vec3
+	texCoords []mgl32.Vec2
+	normals   []mgl32.Vec3
+
+	model *ObjModel
+
+	name     string
+	group    string
+	material string
+
+	mesh       *ObjMesh
+	vertexMap  map[objVertexKey]uint32
+	noNormals  map[uint32]bool
+	lineNumber int
+}
+
+// parses the geometry of an .obj file
+// materials referenced with usemtl are a DefaultMaterial with their name,
+// use LoadObj to also read the .mtl files listed in MaterialLibs
+func ParseObj(r io.Reader) (*ObjModel, error) {
+	p := objParser{
+		model: &ObjModel{
+			Materials: make(map[string]*Material),
+		},
+	}
+
+	err := readObjLines(r, func(lineNumber int, keyword string, args []string) error {
+		p.lineNumber = lineNumber
+		return p.parseLine(keyword, args)
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.finishMesh()
+
+	return p.model, nil
+}
+
+func (p *objParser) parseLine(keyword string, args []string) error {
+	switch keyword {
+	case "v":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		p.positions = append(p.positions, mgl32.Vec3{v[0], v[1], v[2]})
+	case "vt":
+		v, err := parseFloats(args, 1)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		uv := mgl32.Vec2{v[0], 0}
+		if len(v) > 1 {
+			uv[1] = v[1]
+		}
+		p.texCoords = append(p.texCoords, uv)
+	case "vn":
+		v, err := parseFloats(args, 3)
+		if err != nil {
+			return p.errorf("%v", err)
+		}
+		p.normals = append(p.normals, mgl32.Vec3{v[0], v[1], v[2]})
+	case "f":
+		return p.parseFace(args)
+	case "o":
+		p.finishMesh()
+		p.name = strings.Join(args, " ")
+		p.group = ""
+	case "g":
+		p.finishMesh()
+		p.group = strings.Join(args, " ")
+	case "usemtl":
+		p.finishMesh()
+		p.material = strings.Join(args, " ")
+	case "mtllib":
+		p.model.MaterialLibs = append(p.model.MaterialLibs, args...)
+	}
+	// anything else (s, l, p, curves...) isn't needed for drawing
+	return nil
+}
+
+func (p *objParser) parseFace(args []string) error {
+	if len(args) < 3 {
+		return p.errorf("face needs at least 3 vertices but has %d", len(args))
+	}
+
+	if p.mesh == nil {
+		p.mesh = &ObjMesh{
+			Name:  p.name,
+			Group: p.group,
+		}
+		if p.material != "" {
+			p.mesh.Material = DefaultMaterial(p.material)
+		}
+		p.vertexMap = make(map[objVertexKey]uint32)
+		p.noNormals = make(map[uint32]bool)
+	}
+
+	indices := make([]uint32, len(args))
+	for i, arg := range args {
+		key, err := p.parseFaceVertex(arg)
+		if err != nil {
+			return err
+		}
+		indices[i] = p.addVertex(key)
+	}
+
+	// fan triangulation, fine for the convex polygons exporters write
+	for i := 1; i < len(indices)-1; i++ {
+		p.mesh.Indices = append(p.mesh.Indices, indices[0], indices[i], indices[i+1])
+	}
+	return nil
+}
+
+// parses one of v, v/vt, v//vn or v/vt/vn into 0 based indices
+// a missing element is stored as -1
+func (p *objParser) parseFaceVertex(arg string) (objVertexKey, error) {
+	parts := strings.Split(arg, "/")
+	if len(parts) > 3 {
+		return objVertexKey{}, p.errorf("invalid face vertex %q", arg)
+	}
+
+	key := objVertexKey{-1, -1, -1}
+	counts := [3]int{len(p.positions), len(p.texCoords), len(p.normals)}
+	for i, part := range parts {
+		if part == "" {
+			if i == 0 {
+				return objVertexKey{}, p.errorf("face vertex %q has no position", arg)
+			}
+			continue
+		}
+
+		index, err := strconv.Atoi(part)
+		if err != nil {
+			return objVertexKey{}, p.errorf("invalid face vertex %q", arg)
+		}
+		//negative indices count back from the latest element
+		if index < 0 {
+			index += counts[i]
+		} else {
+			index--
+		}
+		if index < 0 || index >= counts[i] {
+			return objVertexKey{}, p.errorf("face vertex %q is out of range", arg)
+		}
+
+		switch i {
+		case 0:
+			key.v = index
+		case 1:
+			key.vt = index
+		case 2:
+			key.vn = index
+		}
+	}
+	return key, nil
+}
+
+func (p *objParser) addVertex(key objVertexKey) uint32 {
+	if index, ok := p.vertexMap[key]; ok {
+		return index
+	}
+
+	index := uint32(len(p.mesh.Verticies) / 5)
+	p.vertexMap[key] = index
+
+	pos := p.positions[key.v]
+	var uv mgl32.Vec2
+	if key.vt >= 0 {
+		uv = p.texCoords[key.vt]
+	}
+	p.mesh.Verticies = append(p.mesh.Verticies, pos.X(), pos.Y(), pos.Z(), uv.X(), uv.Y())
+
+	var normal mgl32.Vec3
+	if key.vn >= 0 {
+		normal = p.normals[key.vn]
+	} else {
+		p.noNormals[index] = true
+	}
+	p.mesh.Normals = append(p.mesh.Normals, normal.X(), normal.Y(), normal.Z())
+
+	return index
+}
+
+// vertices without a vn get a normal averaged from their faces
+func (p *objParser) finishMesh() {
+	if p.mesh == nil {
+		return
+	}
+
+	if len(p.noNormals) > 0 {
+		o := Object{
+			Verticies:    p.mesh.Verticies,
+			VertexStride: 5,
+			Indices:      p.mesh.Indices,
+		}
+		o.CalcNormals(len(o.Indices) / 3)
+
+		for i := range p.noNormals {
+			copy(p.mesh.Normals[i*3:i*3+3], o.normals[i*3:i*3+3])
+		}
+	}
+
+	p.model.Meshes = append(p.model.Meshes, *p.mesh)
+	p.mesh = nil
+}
+
+func (p *objParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("line %d: %s", p.lineNumber, fmt.Sprintf(format, args...))
+}
+
+// parses the materials in an .mtl file keyed by their name
+func ParseMtl(r io.Reader) (map[string]*Material, error) {
+	materials := make(map[string]*Material)
+	var current *Material
+
+	err := readObjLines(r, func(lineNumber int, keyword string, args []string) error {
+		if keyword == "newmtl" {
+			current = &Material{
+				Name:    strings.Join(args, " "),
+				Opacity: 1,
+				IOR:     1,
+			}
+			materials[current.Name] = current
+			return nil
+		}
+		if current == nil {
+			return fmt.Errorf("line %d: %s before newmtl", lineNumber, keyword)
+		}
+
+		var err error
+		switch keyword {
+		case "Ka":
+			current.Ambient, err = parseVec3(args)
+		case "Kd":
+			current.Diffuse, err = parseVec3(args)
+		case "Ks":
+			current.Specular, err = parseVec3(args)
+		case "Ke":
+			current.Emissive, err = parseVec3(args)
+		case "Ns":
+			current.Shininess, err = parseFloat(args)
+		case "Ni":
+			current.IOR, err = parseFloat(args)
+		case "d":
+			current.Opacity, err = parseFloat(args)
+		case "Tr":
+			var tr float32
+			tr, err = parseFloat(args)
+			current.Opacity = 1 - tr
+		case "illum":
+			current.Illum, err = strconv.Atoi(firstArg(args))
+		case "map_Ka":
+			current.AmbientMap = mapPath(args)
+		case "map_Kd":
+			current.DiffuseMap = mapPath(args)
+		case "map_Ks":
+			current.SpecularMap = mapPath(args)
+		case "map_Bump", "map_bump", "bump", "norm":
+			current.NormalMap = mapPath(args)
+		case "map_d":
+			current.AlphaMap = mapPath(args)
+		}
+		if err != nil {
+			return fmt.Errorf("line %d: %s: %w", lineNumber, keyword, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return materials, nil
+}
+
+// makes the map paths relative to the root of fsys instead of the .mtl file
+func (m *Material) resolveMaps(fsys fs.FS, dir string) error {
+	for _, p := range []*string{&m.AmbientMap, &m.DiffuseMap, &m.SpecularMap, &m.NormalMap, &m.AlphaMap} {
+		if *p == "" {
+			continue
+		}
+		if _, ok := fsys.(glsl.OSFS); ok {
+			if !filepath.IsAbs(*p) {
+				*p = filepath.Join(dir, *p)
+			}
+			continue
+		}
+
+		//exporters on windows write \ which is never a separator in an fs.FS
+		resolved := path.Join(dir, strings.ReplaceAll(*p, "\\", "/"))
+		if !fs.ValidPath(resolved) {
+			return fmt.Errorf("texture map %q of material %s is outside the file system", *p, m.Name)
+		}
+		*p = resolved
+	}
+	return nil
+}
+
+// calls parseLine for every non empty line with comments
+// removed and lines ending in \ joined onto the next
+func readObjLines(r io.Reader, parseLine func(lineNumber int, keyword string, args []string) error) error {
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	line := ""
+	for scanner.Scan() {
+		lineNumber++
+		line += scanner.Text()
+		if strings.HasSuffix(line, "\\") {
+			line = strings.TrimSuffix(line, "\\") + " "
+			continue
+		}
+
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		fields := strings.Fields(line)
+		line = ""
+		if len(fields) == 0 {
+			continue
+		}
+
+		if err := parseLine(lineNumber, fields[0], fields[1:]); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func parseFloats(args []string, minCount int) ([]float32, error) {
+	if len(args) < minCount {
+		return nil, fmt.Errorf("expected at least %d values but got %d", minCount, len(args))
+	}
+
+	values := make([]float32, len(args))
+	for i, arg := range args {
+		v, err := strconv.ParseFloat(arg, 32)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = float32(v)
+	}
+	return values, nil
+}
+
+func parseFloat(args []string) (float32, error) {
+	v, err := parseFloats(args, 1)
+	if err != nil {
+		return 0, err
+	}
+	return v[0], nil
+}
+
+// a single value is used for all 3 components
+func parseVec3(args []string) (mgl32.Vec3, error) {
+	v, err := parseFloats(args, 1)
+	if err != nil {
+		return mgl32.Vec3{}, err
+	}
+	if len(v) < 3 {
+		return mgl32.Vec3{v[0], v[0], v[0]}, nil
+	}
+	return mgl32.Vec3{v[0], v[1], v[2]}, nil
+}
+
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// map statements can have options like -bm 1.0 before the
+// file name so the path is always the last argument
+func mapPath(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[len(args)-1]
+}
@@ -0,0 +1,271 @@
+package gogl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseObjFaces(t *testing.T) {
+	const positions = `
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0
+vt 0 0
+vt 1 0
+vt 1 1
+vn 0 0 1
+`
+	tests := []struct {
+		name      string
+		faces     string
+		vertices  []float32
+		normals   []float32
+		indices   []uint32
+		wantError string
+	}{
+		{
+			name:     "positions only",
+			faces:    "f 1 2 3",
+			vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 1, 0, 0, 0},
+			normals:  []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+			indices:  []uint32{0, 1, 2},
+		},
+		{
+			name:     "uvs",
+			faces:    "f 1/1 2/2 3/3",
+			vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 1, 0, 1, 1},
+			normals:  []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+			indices:  []uint32{0, 1, 2},
+		},
+		{
+			name:     "normals without uvs",
+			faces:    "f 1//1 2//1 3//1",
+			vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 1, 0, 0, 0},
+			normals:  []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+			indices:  []uint32{0, 1, 2},
+		},
+		{
+			name:     "uvs and normals",
+			faces:    "f 1/1/1 2/2/1 3/3/1",
+			vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 1, 0, 1, 1},
+			normals:  []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+			indices:  []uint32{0, 1, 2},
+		},
+		{
+			name:     "negative indices",
+			faces:    "f -4/-3/-1 -3/-2/-1 -2/-1/-1",
+			vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 1, 0, 1, 1, 0, 1, 1},
+			normals:  []float32{0, 0, 1, 0, 0, 1, 0, 0, 1},
+			indices:  []uint32{0, 1, 2},
+		},
+		{
+			name:     "quad is fanned and shares vertices",
+			faces:    "f 1 2 3 4",
+			vertices: []float32{0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 1, 1, 0, 0, 0, 0, 1, 0, 0, 0},
+			normals:  []float32{0, 0, 1, 0, 0, 1, 0, 0, 1, 0, 0, 1},
+			indices:  []uint32{0, 1, 2, 0, 2, 3},
+		},
+		{name: "index out of range", faces: "f 1 2 5", wantError: "out of range"},
+		{name: "negative index out of range", faces: "f 1 2 -5", wantError: "out of range"},
+		{name: "uv out of range", faces: "f 1/4 2/1 3/1", wantError: "out of range"},
+		{name: "too few vertices", faces: "f 1 2", wantError: "at least 3"},
+		{name: "no position", faces: "f /1 2 3", wantError: "no position"},
+		{name: "not a number", faces: "f 1 a 3", wantError: "invalid face vertex"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			model, err := ParseObj(strings.NewReader(positions + test.faces))
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("got error %v, want one containing %q", err, test.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(model.Meshes) != 1 {
+				t.Fatalf("got %d meshes, want 1", len(model.Meshes))
+			}
+			mesh := model.Meshes[0]
+			if !slices.Equal(mesh.Verticies, test.vertices) {
+				t.Errorf("vertices = %v, want %v", mesh.Verticies, test.vertices)
+			}
+			if !slices.Equal(mesh.Normals, test.normals) {
+				t.Errorf("normals = %v, want %v", mesh.Normals, test.normals)
+			}
+			if !slices.Equal(mesh.Indices, test.indices) {
+				t.Errorf("indices = %v, want %v", mesh.Indices, test.indices)
+			}
+		})
+	}
+}
+
+func TestParseObjMeshes(t *testing.T) {
+	model, err := ParseObj(strings.NewReader(`
+mtllib a.mtl b.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+o first
+usemtl red
+f 1 2 3
+g part
+f 1 2 3
+usemtl blue
+f 1 2 3
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !slices.Equal(model.MaterialLibs, []string{"a.mtl", "b.mtl"}) {
+		t.Errorf("material libs = %v", model.MaterialLibs)
+	}
+	want := []struct{ name, group, material string }{
+		{"first", "", "red"},
+		{"first", "part", "red"},
+		{"first", "part", "blue"},
+	}
+	if len(model.Meshes) != len(want) {
+		t.Fatalf("got %d meshes, want %d", len(model.Meshes), len(want))
+	}
+	for i, w := range want {
+		mesh := model.Meshes[i]
+		if mesh.Name != w.name || mesh.Group != w.group || mesh.Material.Name != w.material {
+			t.Errorf("mesh %d is %s/%s with %s, want %s/%s with %s",
+				i, mesh.Name, mesh.Group, mesh.Material.Name, w.name, w.group, w.material)
+		}
+		if mesh.Material.Opacity != 1 {
+			t.Errorf("mesh %d has a material with opacity %v, want the default of 1", i, mesh.Material.Opacity)
+		}
+	}
+}
+
+func TestParseMtl(t *testing.T) {
+	materials, err := ParseMtl(strings.NewReader(`
+# a comment
+newmtl shiny metal
+Ka 0.1 0.2 0.3
+Kd 1 0.5 0
+Ns 96
+Tr 0.25
+illum 2
+map_Kd -bm 1 textures/metal.png
+map_Bump normal.png
+
+newmtl glass
+d 0.5
+Ni 1.5
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metal := materials["shiny metal"]
+	if metal == nil {
+		t.Fatalf("missing material, got %v", materials)
+	}
+	if metal.Ambient.X() != 0.1 || metal.Diffuse.Y() != 0.5 || metal.Shininess != 96 || metal.Illum != 2 {
+		t.Errorf("wrong colours or shininess: %+v", metal)
+	}
+	if metal.Opacity != 0.75 {
+		t.Errorf("opacity from Tr = %v, want 0.75", metal.Opacity)
+	}
+	if metal.DiffuseMap != "textures/metal.png" || metal.NormalMap != "normal.png" {
+		t.Errorf("maps are %q and %q", metal.DiffuseMap, metal.NormalMap)
+	}
+
+	glass := materials["glass"]
+	if glass.Opacity != 0.5 || glass.IOR != 1.5 {
+		t.Errorf("glass = %+v", glass)
+	}
+
+	if _, err := ParseMtl(strings.NewReader("Kd 1 1 1")); err == nil {
+		t.Error("a property before newmtl should be an error")
+	}
+	if _, err := ParseMtl(strings.NewReader("newmtl a\nKd 1 x 1")); err == nil {
+		t.Error("an invalid colour should be an error")
+	}
+}
+
+func TestLoadObjMaterials(t *testing.T) {
+	fsys := fstest.MapFS{
+		"models/cube.obj": {Data: []byte(`
+mtllib materials/cube.mtl
+v 0 0 0
+v 1 0 0
+v 0 1 0
+usemtl wood
+f 1 2 3
+usemtl missing
+f 1 2 3
+`)},
+		"models/materials/cube.mtl": {Data: []byte(`
+newmtl wood
+Kd 0.6 0.4 0.2
+map_Kd ..\textures\wood.png
+`)},
+	}
+
+	model, err := LoadObjFS(fsys, "models/cube.obj")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wood := model.Meshes[0].Material
+	if wood != model.Materials["wood"] {
+		t.Errorf("the first mesh should use the loaded wood material, got %+v", wood)
+	}
+	if wood.DiffuseMap != "models/textures/wood.png" {
+		t.Errorf("diffuse map = %q, want it relative to the file system", wood.DiffuseMap)
+	}
+
+	missing := model.Meshes[1].Material
+	if missing.Name != "missing" || *missing != *DefaultMaterial("missing") {
+		t.Errorf("a missing material should be the default, got %+v", missing)
+	}
+	if !slices.Equal(model.MissingMaterials, []string{"missing"}) {
+		t.Errorf("missing materials = %v", model.MissingMaterials)
+	}
+}
+
+func TestLoadObjMissingMtl(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cube.obj": {Data: []byte("mtllib gone.mtl\nmtllib cube.mtl\nv 0 0 0\nusemtl stone\nf 1 1 1\n")},
+		"cube.mtl": {Data: []byte("newmtl wood\nKd 0.6 0.4 0.2\n")},
+	}
+
+	model, err := LoadObjFS(fsys, "cube.obj")
+	if err != nil {
+		t.Fatalf("a missing .mtl file shouldn't fail the load: %v", err)
+	}
+	if !slices.Equal(model.MissingMaterialLibs, []string{"gone.mtl"}) {
+		t.Errorf("missing material libs = %v", model.MissingMaterialLibs)
+	}
+	if model.Materials["wood"] == nil {
+		t.Error("the .mtl file after the missing one wasn't loaded")
+	}
+	if stone := model.Meshes[0].Material; *stone != *DefaultMaterial("stone") {
+		t.Errorf("a material from the missing file should be the default, got %+v", stone)
+	}
+
+	fsys["gone.mtl"] = &fstest.MapFile{Data: []byte("Kd 1 1 1\n")}
+	if _, err := LoadObjFS(fsys, "cube.obj"); err == nil {
+		t.Error("an .mtl file that exists but can't be parsed should still be an error")
+	}
+}
+
+func TestLoadObjMapOutsideFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"cube.obj": {Data: []byte("mtllib cube.mtl\n")},
+		"cube.mtl": {Data: []byte("newmtl a\nmap_Kd ../secret.png\n")},
+	}
+	if _, err := LoadObjFS(fsys, "cube.obj"); err == nil {
+		t.Error("a map outside the file system should be an error")
+	}
+}
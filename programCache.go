@@ -0,0 +1,237 @@
+package gogl
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// bumped whenever the layout of the cache files changes
+const programCacheVersion = 1
+
+// stores linked program binaries on disk so later runs can skip compiling
+//
+// binaries are keyed by the final source of every stage and the driver
+// so editing a shader or updating the driver just misses the cache.
+// binaries the driver rejects are deleted and rebuilt from source
+type ProgramCache struct {
+	dir       string
+	driver    string
+	supported bool
+	onError   func(err error)
+}
+
+// the program cache used by every program built from source, nil disables it
+var programCache *ProgramCache
+
+// makes every program built after this go through cache
+// pass nil to go back to always compiling
+func SetProgramCache(cache *ProgramCache) {
+	programCache = cache
+}
+
+// a cache storing binaries in dir, it needs a current gl context
+// if the driver can't give out program binaries the cache does nothing
+func NewProgramCache(dir string) (*ProgramCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	c := ProgramCache{
+		dir: dir,
+		driver: strings.Join([]string{
+			GetVersion(),
+			gl.GoStr(ogl.GetString(gl.RENDERER)),
+			gl.GoStr(ogl.GetString(gl.VENDOR)),
+		}, "\n"),
+		supported: programBinarySupported(),
+	}
+	return &c, nil
+}
+
+// glGetProgramBinary is core in 4.1 and an extension before that
+func programBinarySupported() bool {
+	var major, minor int32
+	ogl.GetIntegerv(gl.MAJOR_VERSION, &major)
+	ogl.GetIntegerv(gl.MINOR_VERSION, &minor)
+	if major < 4 || (major == 4 && minor < 1) {
+		if !hasExtension("GL_ARB_get_program_binary") {
+			return false
+		}
+	}
+
+	var formats int32
+	ogl.GetIntegerv(gl.NUM_PROGRAM_BINARY_FORMATS, &formats)
+	return formats > 0
+}
+
+func hasExtension(name string) bool {
+	var count int32
+	ogl.GetIntegerv(gl.NUM_EXTENSIONS, &count)
+	for i := uint32(0); i < uint32(count); i++ {
+		if gl.GoStr(ogl.GetStringi(gl.EXTENSIONS, i)) == name {
+			return true
+		}
+	}
+	return false
+}
+
+// whether the driver supports program binaries
+func (c *ProgramCache) Supported() bool {
+	return c.supported
+}
+
+func (c *ProgramCache) Dir() string {
+	return c.dir
+}
+
+// callback is run whenever a binary can't be loaded, stored or removed
+// the program is still built from source. By default the error is printed
+func (c *ProgramCache) SetErrorCallback(callback func(err error)) {
+	c.onError = callback
+}
+
+func (c *ProgramCache) report(err error) {
+	if c.onError != nil {
+		c.onError(err)
+	} else {
+		fmt.Println(err)
+	}
+}
+
+// deletes every cached binary
+func (c *ProgramCache) Clear() error {
+	paths, err := filepath.Glob(filepath.Join(c.dir, "*.bin"))
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *ProgramCache) key(stages []StageSource) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%d\n%s\n", programCacheVersion, c.driver)
+	for _, stage := range stages {
+		fmt.Fprintf(hash, "%d %d\n%s\n", stage.Stage, len(stage.Source.Source), stage.Source.Source)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+func (c *ProgramCache) path(key string) string {
+	return filepath.Join(c.dir, key+".bin")
+}
+
+// the program stored under key or false if there isn't one or the driver
+// no longer accepts it. Only a missing binary isn't an error
+func (c *ProgramCache) load(key string) (ProgramID, bool, error) {
+	if !c.supported {
+		return 0, false, nil
+	}
+
+	data, err := os.ReadFile(c.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, err
+	}
+	if len(data) <= 4 {
+		return 0, false, c.remove(key, fmt.Errorf("cached program %s is truncated", c.path(key)))
+	}
+	format := binary.LittleEndian.Uint32(data)
+	programBinary := data[4:]
+
+	id := ogl.CreateProgram()
+	trackResource(ResourceProgram, id)
+	ogl.ProgramParameteri(id, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	ogl.ProgramBinary(id, format, gl.Ptr(programBinary), int32(len(programBinary)))
+
+	var success int32
+	ogl.GetProgramiv(id, gl.LINK_STATUS, &success)
+	if success == gl.FALSE {
+		ProgramID(id).Delete()
+		return 0, false, c.remove(key, fmt.Errorf("the driver rejected cached program %s", c.path(key)))
+	}
+	return ProgramID(id), true, nil
+}
+
+// deletes a bad binary so it's rebuilt next time, along with why it was bad
+func (c *ProgramCache) remove(key string, reason error) error {
+	if err := os.Remove(c.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errors.Join(reason, err)
+	}
+	return reason
+}
+
+// failing to store a binary only means the
+// program is compiled again next time
+func (c *ProgramCache) store(key string, id ProgramID) error {
+	if !c.supported {
+		return nil
+	}
+
+	var length int32
+	ogl.GetProgramiv(uint32(id), gl.PROGRAM_BINARY_LENGTH, &length)
+	if length == 0 {
+		return errors.New("the driver returned an empty program binary")
+	}
+
+	data := make([]byte, 4+length)
+	var format uint32
+	ogl.GetProgramBinary(uint32(id), length, &length, &format, gl.Ptr(data[4:]))
+	binary.LittleEndian.PutUint32(data, format)
+	data = data[:4+length]
+
+	//written to a temporary file first so a crash never leaves half a binary
+	tmp, err := os.CreateTemp(c.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return errors.Join(err, os.Remove(tmp.Name()))
+	}
+	return os.Rename(tmp.Name(), c.path(key))
+}
+
+// builds a program with build unless the program
+// cache already has a binary for the same sources
+func withProgramCache(stages []StageSource, build func() (ProgramID, error)) (ProgramID, error) {
+	cache := programCache
+	if cache == nil {
+		return build()
+	}
+
+	key := cache.key(stages)
+	id, ok, err := cache.load(key)
+	if err != nil {
+		cache.report(err)
+	}
+	if ok {
+		return id, nil
+	}
+
+	id, err = build()
+	if err == nil {
+		if storeErr := cache.store(key, id); storeErr != nil {
+			cache.report(fmt.Errorf("storing program in cache: %w", storeErr))
+		}
+	}
+	return id, err
+}
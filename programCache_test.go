@@ -0,0 +1,122 @@
+package gogl
+
+import (
+	"bytes"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestProgramCacheReportsStoreErrors(t *testing.T) {
+	_, restore := UseRecordingGL()
+	defer restore()
+	cache, reported := testProgramCache(t)
+
+	//with the directory gone the binary can't be written
+	if err := os.RemoveAll(cache.Dir()); err != nil {
+		t.Fatal(err)
+	}
+	id, err := CreateProgramFromStageSources(testStages()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer id.Delete()
+
+	if len(*reported) != 1 || !strings.Contains((*reported)[0].Error(), "storing program in cache") {
+		t.Errorf("reported %v, want a store error", *reported)
+	}
+}
+
+func TestProgramCacheReportsBadBinaries(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      []byte
+		wantError string
+	}{
+		{"truncated", []byte{1, 2}, "is truncated"},
+		{"rejected", []byte{1, 0, 0, 0, 0xDE, 0xAD, 0xBE, 0xEF}, "the driver rejected"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, restore := UseRecordingGL()
+			defer restore()
+			cache, reported := testProgramCache(t)
+
+			path := cache.path(cache.key(testStages()))
+			if err := os.WriteFile(path, test.data, 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := CreateProgramFromStageSources(testStages()...)
+			if err != nil {
+				t.Fatalf("the program should still be built from source: %v", err)
+			}
+			defer id.Delete()
+
+			if len(*reported) == 0 || !strings.Contains((*reported)[0].Error(), test.wantError) {
+				t.Errorf("reported %v, want an error containing %q", *reported, test.wantError)
+			}
+			//the program built from source is stored in its place
+			if data, err := os.ReadFile(path); err != nil || bytes.Equal(data, test.data) {
+				t.Errorf("the bad binary wasn't replaced: %v", err)
+			}
+		})
+	}
+}
+
+func TestProgramCacheMissIsntAnError(t *testing.T) {
+	cache := &ProgramCache{dir: t.TempDir(), supported: true}
+	if _, ok, err := cache.load("missing"); ok || err != nil {
+		t.Errorf("load of a missing binary = %v, %v, want false and no error", ok, err)
+	}
+}
+
+func TestProgramCacheRoundTrip(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	cache, err := NewProgramCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cache.Supported() {
+		t.Fatal("the fake's program binaries aren't supported")
+	}
+	var reported []error
+	cache.SetErrorCallback(func(err error) {
+		reported = append(reported, err)
+	})
+	SetProgramCache(cache)
+	t.Cleanup(func() { SetProgramCache(nil) })
+
+	built, err := CreateProgramFromStageSources(testStages()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer built.Delete()
+	if _, err := os.Stat(cache.path(cache.key(testStages()))); err != nil {
+		t.Fatalf("the binary wasn't stored: %v", err)
+	}
+
+	fake.Reset()
+	loaded, err := CreateProgramFromStageSources(testStages()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer loaded.Delete()
+
+	if len(fake.CallsTo("ProgramBinary")) != 1 || len(fake.CallsTo("CompileShader")) != 0 {
+		t.Errorf("the cached binary wasn't used: %v", fake.Calls)
+	}
+	if len(reported) != 0 {
+		t.Errorf("reported %v", reported)
+	}
+	if got, want := ReflectUniforms(loaded), ReflectUniforms(built); !slices.Equal(got, want) {
+		t.Errorf("cached program has uniforms %v, want %v", got, want)
+	}
+	if got, want := ReflectAttributes(loaded), ReflectAttributes(built); !slices.Equal(got, want) {
+		t.Errorf("cached program has attributes %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,1571 @@
+package gogl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unsafe"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+// a gl call made through a RecordingGL, pointer arguments are left
+// out as the data they point to ends up in the simulated objects
+type GLCall struct {
+	Name string
+	Args []any
+}
+
+func (c GLCall) String() string {
+	args := make([]string, len(c.Args))
+	for i, arg := range c.Args {
+		args[i] = fmt.Sprint(arg)
+	}
+	return c.Name + "(" + strings.Join(args, ", ") + ")"
+}
+
+// a fake gl that needs no gpu or context. It records every call and
+// keeps the state of the buffers, vertex arrays, textures, shaders and
+// programs made through it so tests can check what the package did
+//
+// shaders always compile unless CompileError says otherwise and linked
+// programs reflect the in variables of the vertex shader as attributes
+// and the uniform declarations of every shader as uniforms. Program
+// binaries hold the shader sources so they round trip through a
+// ProgramCache
+type RecordingGL struct {
+	Calls []GLCall
+	// misuse that real gl would report as an error,
+	// e.g. uploading data with no buffer bound
+	Errors []string
+
+	// when set compiling a shader fails if it returns a non empty log
+	CompileError func(shaderType uint32, source string) string
+
+	nextID         uint32
+	buffers        map[uint32]*FakeBuffer
+	vertexArrays   map[uint32]*FakeVertexArray
+	textures       map[uint32]*FakeTexture
+	shaders        map[uint32]*FakeShader
+	programs       map[uint32]*FakeProgram
+	framebuffers   map[uint32]*FakeFramebuffer
+	renderbuffers  map[uint32]*FakeRenderbuffer
+	bufferBindings map[uint32]uint32
+	vertexArray    uint32
+	textureBinding map[uint32]uint32
+	program        uint32
+	framebuffer    uint32
+	renderbuffer   uint32
+	viewport       [4]int32
+	enabled        map[uint32]bool
+	strings        map[uint32][]byte
+	extensions     [][]byte
+}
+
+type FakeBuffer struct {
+	Data   []byte
+	Usage  uint32
+	Mapped bool
+}
+
+type FakeVertexArray struct {
+	Attributes    map[uint32]*FakeAttribute
+	ElementBuffer uint32
+}
+
+type FakeAttribute struct {
+	Buffer     uint32
+	Components int32
+	Type       uint32
+	Normalized bool
+	Integer    bool
+	Stride     int32
+	Offset     uintptr
+	Divisor    uint32
+	Enabled    bool
+}
+
+type FakeTexture struct {
+	Target         uint32
+	Width, Height  int32
+	InternalFormat int32
+	Format, Type   uint32
+	Pixels         []byte
+	Params         map[uint32]int32
+	Mipmaps        bool
+}
+
+type FakeShader struct {
+	Type     uint32
+	Source   string
+	Compiled bool
+	InfoLog  string
+}
+
+type FakeProgram struct {
+	Shaders    []uint32
+	Linked     bool
+	InfoLog    string
+	Attributes []AttributeInfo
+	// the last value set for each uniform as []float32, []int32 or []uint32,
+	// stored under the name of the location it was set at e.g. "lights[1]"
+	Uniforms      map[string]any
+	BlockBindings map[string]uint32
+
+	//the shaders it was linked from which make up its binary
+	stages           []fakeStage
+	uniforms         []UniformInfo
+	uniformLocations map[string]int32
+	uniformNames     []string
+	blockNames       []string
+}
+
+type FakeFramebuffer struct {
+	// the renderbuffer at each attachment e.g. gl.COLOR_ATTACHMENT0
+	Attachments map[uint32]uint32
+}
+
+type FakeRenderbuffer struct {
+	InternalFormat uint32
+	Width, Height  int32
+}
+
+func NewRecordingGL() *RecordingGL {
+	return &RecordingGL{
+		buffers:        make(map[uint32]*FakeBuffer),
+		vertexArrays:   make(map[uint32]*FakeVertexArray),
+		textures:       make(map[uint32]*FakeTexture),
+		shaders:        make(map[uint32]*FakeShader),
+		programs:       make(map[uint32]*FakeProgram),
+		framebuffers:   make(map[uint32]*FakeFramebuffer),
+		renderbuffers:  make(map[uint32]*FakeRenderbuffer),
+		bufferBindings: make(map[uint32]uint32),
+		textureBinding: make(map[uint32]uint32),
+		enabled:        make(map[uint32]bool),
+		strings:        make(map[uint32][]byte),
+		extensions:     [][]byte{[]byte("GL_ARB_get_program_binary\x00")},
+	}
+}
+
+// makes the whole package use a new RecordingGL until restore is called
+//
+//	fake, restore := gogl.UseRecordingGL()
+//	defer restore()
+func UseRecordingGL() (fake *RecordingGL, restore func()) {
+	previous := ogl
+	fake = NewRecordingGL()
+	ogl = fake
+	return fake, func() { ogl = previous }
+}
+
+// the calls made to the gl function name
+func (f *RecordingGL) CallsTo(name string) []GLCall {
+	var calls []GLCall
+	for _, call := range f.Calls {
+		if call.Name == name {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// forgets the recorded calls and errors but keeps every object
+func (f *RecordingGL) Reset() {
+	f.Calls = nil
+	f.Errors = nil
+}
+
+func (f *RecordingGL) Buffer(id BufferID) *FakeBuffer           { return f.buffers[uint32(id)] }
+func (f *RecordingGL) VertexArray(id BufferID) *FakeVertexArray { return f.vertexArrays[uint32(id)] }
+func (f *RecordingGL) Texture(id TextureID) *FakeTexture        { return f.textures[uint32(id)] }
+func (f *RecordingGL) Shader(id ShaderID) *FakeShader           { return f.shaders[uint32(id)] }
+func (f *RecordingGL) Program(id ProgramID) *FakeProgram        { return f.programs[uint32(id)] }
+func (f *RecordingGL) Framebuffer(id uint32) *FakeFramebuffer   { return f.framebuffers[id] }
+func (f *RecordingGL) Renderbuffer(id uint32) *FakeRenderbuffer { return f.renderbuffers[id] }
+
+// the buffer bound to target, e.g. gl.ARRAY_BUFFER
+func (f *RecordingGL) BoundBuffer(target uint32) BufferID {
+	return BufferID(f.bufferBinding(target))
+}
+
+func (f *RecordingGL) BoundVertexArray() BufferID {
+	return BufferID(f.vertexArray)
+}
+
+func (f *RecordingGL) CurrentProgram() ProgramID {
+	return ProgramID(f.program)
+}
+
+func (f *RecordingGL) BoundFramebuffer() uint32 {
+	return f.framebuffer
+}
+
+// the x, y, width and height set with glViewport
+func (f *RecordingGL) CurrentViewport() [4]int32 {
+	return f.viewport
+}
+
+func (f *RecordingGL) IsEnabled(capability uint32) bool {
+	return f.enabled[capability]
+}
+
+// the ids of every object of a kind, like ResourceBuffer, that hasn't been deleted
+func (f *RecordingGL) Live(kind string) []uint32 {
+	var ids []uint32
+	switch kind {
+	case ResourceBuffer:
+		ids = mapKeys(f.buffers)
+	case ResourceVertexArray:
+		ids = mapKeys(f.vertexArrays)
+	case ResourceTexture:
+		ids = mapKeys(f.textures)
+	case ResourceShader:
+		ids = mapKeys(f.shaders)
+	case ResourceProgram:
+		ids = mapKeys(f.programs)
+	case ResourceFramebuffer:
+		ids = mapKeys(f.framebuffers)
+	case ResourceRenderbuffer:
+		ids = mapKeys(f.renderbuffers)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func mapKeys[V any](m map[uint32]V) []uint32 {
+	keys := make([]uint32, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (f *RecordingGL) record(name string, args ...any) {
+	f.Calls = append(f.Calls, GLCall{name, args})
+}
+
+func (f *RecordingGL) fail(format string, args ...any) {
+	f.Errors = append(f.Errors, fmt.Sprintf(format, args...))
+}
+
+// objects of every type share the ids so they're never confused
+func (f *RecordingGL) newID() uint32 {
+	f.nextID++
+	return f.nextID
+}
+
+// the element buffer is part of the bound vertex array
+func (f *RecordingGL) bufferBinding(target uint32) uint32 {
+	if target == gl.ELEMENT_ARRAY_BUFFER {
+		if va := f.vertexArrays[f.vertexArray]; va != nil {
+			return va.ElementBuffer
+		}
+		return 0
+	}
+	return f.bufferBindings[target]
+}
+
+func (f *RecordingGL) boundBuffer(function string, target uint32) *FakeBuffer {
+	b := f.buffers[f.bufferBinding(target)]
+	if b == nil {
+		f.fail("%s: no buffer is bound to target 0x%X", function, target)
+	}
+	return b
+}
+
+// buffers
+
+func (f *RecordingGL) GenBuffers(n int32, buffers *uint32) {
+	ids := unsafe.Slice(buffers, n)
+	for i := range ids {
+		ids[i] = f.newID()
+		f.buffers[ids[i]] = &FakeBuffer{}
+	}
+	f.record("GenBuffers", n, append([]uint32(nil), ids...))
+}
+
+func (f *RecordingGL) DeleteBuffers(n int32, buffers *uint32) {
+	ids := unsafe.Slice(buffers, n)
+	f.record("DeleteBuffers", n, append([]uint32(nil), ids...))
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := f.buffers[id]; !ok {
+			f.fail("DeleteBuffers: buffer %d doesn't exist", id)
+		}
+		delete(f.buffers, id)
+		for target, bound := range f.bufferBindings {
+			if bound == id {
+				delete(f.bufferBindings, target)
+			}
+		}
+		if va := f.vertexArrays[f.vertexArray]; va != nil && va.ElementBuffer == id {
+			va.ElementBuffer = 0
+		}
+	}
+}
+
+func (f *RecordingGL) BindBuffer(target uint32, buffer uint32) {
+	f.record("BindBuffer", target, buffer)
+	if _, ok := f.buffers[buffer]; !ok && buffer != 0 {
+		f.fail("BindBuffer: buffer %d doesn't exist", buffer)
+	}
+	if target == gl.ELEMENT_ARRAY_BUFFER {
+		if va := f.vertexArrays[f.vertexArray]; va != nil {
+			va.ElementBuffer = buffer
+		} else if buffer != 0 {
+			f.fail("BindBuffer: binding an element buffer with no vertex array bound")
+		}
+		return
+	}
+	f.bufferBindings[target] = buffer
+}
+
+func (f *RecordingGL) BindBufferBase(target uint32, index uint32, buffer uint32) {
+	f.record("BindBufferBase", target, index, buffer)
+	if _, ok := f.buffers[buffer]; !ok && buffer != 0 {
+		f.fail("BindBufferBase: buffer %d doesn't exist", buffer)
+	}
+	f.bufferBindings[target] = buffer
+}
+
+func (f *RecordingGL) BufferData(target uint32, size int, data unsafe.Pointer, usage uint32) {
+	f.record("BufferData", target, size, usage)
+	b := f.boundBuffer("BufferData", target)
+	if b == nil {
+		return
+	}
+	b.Data = make([]byte, size)
+	b.Usage = usage
+	if data != nil {
+		copy(b.Data, unsafe.Slice((*byte)(data), size))
+	}
+}
+
+func (f *RecordingGL) BufferSubData(target uint32, offset int, size int, data unsafe.Pointer) {
+	f.record("BufferSubData", target, offset, size)
+	b := f.boundBuffer("BufferSubData", target)
+	if b == nil {
+		return
+	}
+	if offset < 0 || offset+size > len(b.Data) {
+		f.fail("BufferSubData: writing %d bytes at %d is outside the buffer of %d bytes", size, offset, len(b.Data))
+		return
+	}
+	copy(b.Data[offset:], unsafe.Slice((*byte)(data), size))
+}
+
+func (f *RecordingGL) CopyBufferSubData(readTarget uint32, writeTarget uint32, readOffset int, writeOffset int, size int) {
+	f.record("CopyBufferSubData", readTarget, writeTarget, readOffset, writeOffset, size)
+	read := f.boundBuffer("CopyBufferSubData", readTarget)
+	write := f.boundBuffer("CopyBufferSubData", writeTarget)
+	if read == nil || write == nil {
+		return
+	}
+	if readOffset+size > len(read.Data) || writeOffset+size > len(write.Data) {
+		f.fail("CopyBufferSubData: copying %d bytes is outside the buffers", size)
+		return
+	}
+	copy(write.Data[writeOffset:writeOffset+size], read.Data[readOffset:readOffset+size])
+}
+
+func (f *RecordingGL) GetBufferParameteriv(target uint32, pname uint32, params *int32) {
+	f.record("GetBufferParameteriv", target, pname)
+	b := f.boundBuffer("GetBufferParameteriv", target)
+	if b == nil {
+		return
+	}
+	switch pname {
+	case gl.BUFFER_SIZE:
+		*params = int32(len(b.Data))
+	case gl.BUFFER_USAGE:
+		*params = int32(b.Usage)
+	case gl.BUFFER_MAPPED:
+		*params = glBool(b.Mapped)
+	default:
+		f.fail("GetBufferParameteriv: unsupported parameter 0x%X", pname)
+	}
+}
+
+func (f *RecordingGL) MapBufferRange(target uint32, offset int, length int, access uint32) unsafe.Pointer {
+	f.record("MapBufferRange", target, offset, length, access)
+	b := f.boundBuffer("MapBufferRange", target)
+	if b == nil {
+		return nil
+	}
+	if b.Mapped || length <= 0 || offset < 0 || offset+length > len(b.Data) {
+		f.fail("MapBufferRange: can't map %d bytes at %d of a buffer of %d bytes", length, offset, len(b.Data))
+		return nil
+	}
+	b.Mapped = true
+	return unsafe.Pointer(&b.Data[offset])
+}
+
+func (f *RecordingGL) UnmapBuffer(target uint32) bool {
+	f.record("UnmapBuffer", target)
+	b := f.boundBuffer("UnmapBuffer", target)
+	if b == nil || !b.Mapped {
+		f.fail("UnmapBuffer: the buffer isn't mapped")
+		return false
+	}
+	b.Mapped = false
+	return true
+}
+
+// vertex arrays
+
+func (f *RecordingGL) GenVertexArrays(n int32, arrays *uint32) {
+	ids := unsafe.Slice(arrays, n)
+	for i := range ids {
+		ids[i] = f.newID()
+		f.vertexArrays[ids[i]] = &FakeVertexArray{Attributes: make(map[uint32]*FakeAttribute)}
+	}
+	f.record("GenVertexArrays", n, append([]uint32(nil), ids...))
+}
+
+func (f *RecordingGL) DeleteVertexArrays(n int32, arrays *uint32) {
+	ids := unsafe.Slice(arrays, n)
+	f.record("DeleteVertexArrays", n, append([]uint32(nil), ids...))
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := f.vertexArrays[id]; !ok {
+			f.fail("DeleteVertexArrays: vertex array %d doesn't exist", id)
+		}
+		delete(f.vertexArrays, id)
+		if f.vertexArray == id {
+			f.vertexArray = 0
+		}
+	}
+}
+
+func (f *RecordingGL) BindVertexArray(array uint32) {
+	f.record("BindVertexArray", array)
+	if _, ok := f.vertexArrays[array]; !ok && array != 0 {
+		f.fail("BindVertexArray: vertex array %d doesn't exist", array)
+	}
+	f.vertexArray = array
+}
+
+// the attribute at index of the bound vertex array
+func (f *RecordingGL) attribute(function string, index uint32) *FakeAttribute {
+	va := f.vertexArrays[f.vertexArray]
+	if va == nil {
+		f.fail("%s: no vertex array is bound", function)
+		return nil
+	}
+	a := va.Attributes[index]
+	if a == nil {
+		a = &FakeAttribute{}
+		va.Attributes[index] = a
+	}
+	return a
+}
+
+func (f *RecordingGL) setAttribute(function string, index uint32, size int32, xtype uint32, normalized, integer bool, stride int32, offset uintptr) {
+	a := f.attribute(function, index)
+	if a == nil {
+		return
+	}
+	buffer := f.bufferBindings[gl.ARRAY_BUFFER]
+	if buffer == 0 {
+		f.fail("%s: no array buffer is bound", function)
+	}
+	a.Buffer = buffer
+	a.Components = size
+	a.Type = xtype
+	a.Normalized = normalized
+	a.Integer = integer
+	a.Stride = stride
+	a.Offset = offset
+}
+
+func (f *RecordingGL) VertexAttribPointer(index uint32, size int32, xtype uint32, normalized bool, stride int32, pointer unsafe.Pointer) {
+	f.record("VertexAttribPointer", index, size, xtype, normalized, stride, uintptr(pointer))
+	f.setAttribute("VertexAttribPointer", index, size, xtype, normalized, false, stride, uintptr(pointer))
+}
+
+func (f *RecordingGL) VertexAttribPointerWithOffset(index uint32, size int32, xtype uint32, normalized bool, stride int32, offset uintptr) {
+	f.record("VertexAttribPointerWithOffset", index, size, xtype, normalized, stride, offset)
+	f.setAttribute("VertexAttribPointerWithOffset", index, size, xtype, normalized, false, stride, offset)
+}
+
+func (f *RecordingGL) VertexAttribIPointerWithOffset(index uint32, size int32, xtype uint32, stride int32, offset uintptr) {
+	f.record("VertexAttribIPointerWithOffset", index, size, xtype, stride, offset)
+	f.setAttribute("VertexAttribIPointerWithOffset", index, size, xtype, false, true, stride, offset)
+}
+
+func (f *RecordingGL) VertexAttribDivisor(index uint32, divisor uint32) {
+	f.record("VertexAttribDivisor", index, divisor)
+	if a := f.attribute("VertexAttribDivisor", index); a != nil {
+		a.Divisor = divisor
+	}
+}
+
+func (f *RecordingGL) EnableVertexAttribArray(index uint32) {
+	f.record("EnableVertexAttribArray", index)
+	if a := f.attribute("EnableVertexAttribArray", index); a != nil {
+		a.Enabled = true
+	}
+}
+
+func (f *RecordingGL) DisableVertexAttribArray(index uint32) {
+	f.record("DisableVertexAttribArray", index)
+	if a := f.attribute("DisableVertexAttribArray", index); a != nil {
+		a.Enabled = false
+	}
+}
+
+// drawing
+
+func (f *RecordingGL) checkDraw(function string, indexed bool) {
+	va := f.vertexArrays[f.vertexArray]
+	if va == nil {
+		f.fail("%s: no vertex array is bound", function)
+		return
+	}
+	if indexed && va.ElementBuffer == 0 {
+		f.fail("%s: the vertex array has no element buffer", function)
+	}
+	if p := f.programs[f.program]; p == nil || !p.Linked {
+		f.fail("%s: no linked program is in use", function)
+	}
+}
+
+func (f *RecordingGL) DrawArrays(mode uint32, first int32, count int32) {
+	f.record("DrawArrays", mode, first, count)
+	f.checkDraw("DrawArrays", false)
+}
+
+func (f *RecordingGL) DrawArraysInstanced(mode uint32, first int32, count int32, instancecount int32) {
+	f.record("DrawArraysInstanced", mode, first, count, instancecount)
+	f.checkDraw("DrawArraysInstanced", false)
+}
+
+func (f *RecordingGL) DrawElementsWithOffset(mode uint32, count int32, xtype uint32, indices uintptr) {
+	f.record("DrawElementsWithOffset", mode, count, xtype, indices)
+	f.checkDraw("DrawElementsWithOffset", true)
+}
+
+func (f *RecordingGL) DrawElementsInstanced(mode uint32, count int32, xtype uint32, indices unsafe.Pointer, instancecount int32) {
+	f.record("DrawElementsInstanced", mode, count, xtype, uintptr(indices), instancecount)
+	f.checkDraw("DrawElementsInstanced", true)
+}
+
+// textures
+
+func (f *RecordingGL) GenTextures(n int32, textures *uint32) {
+	ids := unsafe.Slice(textures, n)
+	for i := range ids {
+		ids[i] = f.newID()
+		f.textures[ids[i]] = &FakeTexture{Params: make(map[uint32]int32)}
+	}
+	f.record("GenTextures", n, append([]uint32(nil), ids...))
+}
+
+func (f *RecordingGL) DeleteTextures(n int32, textures *uint32) {
+	ids := unsafe.Slice(textures, n)
+	f.record("DeleteTextures", n, append([]uint32(nil), ids...))
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := f.textures[id]; !ok {
+			f.fail("DeleteTextures: texture %d doesn't exist", id)
+		}
+		delete(f.textures, id)
+		for target, bound := range f.textureBinding {
+			if bound == id {
+				delete(f.textureBinding, target)
+			}
+		}
+	}
+}
+
+func (f *RecordingGL) BindTexture(target uint32, texture uint32) {
+	f.record("BindTexture", target, texture)
+	if texture == 0 {
+		f.textureBinding[target] = 0
+		return
+	}
+	t := f.textures[texture]
+	if t == nil {
+		f.fail("BindTexture: texture %d doesn't exist", texture)
+		return
+	}
+	if t.Target != 0 && t.Target != target {
+		f.fail("BindTexture: texture %d was first bound to 0x%X not 0x%X", texture, t.Target, target)
+	}
+	t.Target = target
+	f.textureBinding[target] = texture
+}
+
+func (f *RecordingGL) boundTexture(function string, target uint32) *FakeTexture {
+	t := f.textures[f.textureBinding[target]]
+	if t == nil {
+		f.fail("%s: no texture is bound to target 0x%X", function, target)
+	}
+	return t
+}
+
+func (f *RecordingGL) TexImage2D(target uint32, level int32, internalformat int32, width int32, height int32, border int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	f.record("TexImage2D", target, level, internalformat, width, height, border, format, xtype)
+	t := f.boundTexture("TexImage2D", target)
+	if t == nil || level != 0 {
+		return
+	}
+	t.Width, t.Height = width, height
+	t.InternalFormat = internalformat
+	t.Format, t.Type = format, xtype
+	t.Pixels = nil
+	if pixels != nil {
+		size := int(width) * int(height) * pixelSize(format, xtype)
+		t.Pixels = append([]byte(nil), unsafe.Slice((*byte)(pixels), size)...)
+	}
+}
+
+// the size of a pixel in bytes for the formats the package uploads
+func pixelSize(format, xtype uint32) int {
+	channels := 4
+	switch format {
+	case gl.RED, gl.RED_INTEGER, gl.DEPTH_COMPONENT:
+		channels = 1
+	case gl.RG, gl.RG_INTEGER, gl.DEPTH_STENCIL:
+		channels = 2
+	case gl.RGB, gl.BGR, gl.RGB_INTEGER:
+		channels = 3
+	}
+
+	switch xtype {
+	case gl.BYTE, gl.UNSIGNED_BYTE:
+		return channels
+	case gl.SHORT, gl.UNSIGNED_SHORT, gl.HALF_FLOAT:
+		return channels * 2
+	}
+	return channels * 4
+}
+
+func (f *RecordingGL) TexParameteri(target uint32, pname uint32, param int32) {
+	f.record("TexParameteri", target, pname, param)
+	if t := f.boundTexture("TexParameteri", target); t != nil {
+		t.Params[pname] = param
+	}
+}
+
+func (f *RecordingGL) GenerateMipmap(target uint32) {
+	f.record("GenerateMipmap", target)
+	if t := f.boundTexture("GenerateMipmap", target); t != nil {
+		if t.Width == 0 || t.Height == 0 {
+			f.fail("GenerateMipmap: texture has no image")
+		}
+		t.Mipmaps = true
+	}
+}
+
+// shaders and programs
+
+func (f *RecordingGL) CreateShader(xtype uint32) uint32 {
+	id := f.newID()
+	f.shaders[id] = &FakeShader{Type: xtype}
+	f.record("CreateShader", xtype)
+	return id
+}
+
+func (f *RecordingGL) DeleteShader(shader uint32) {
+	f.record("DeleteShader", shader)
+	if _, ok := f.shaders[shader]; !ok && shader != 0 {
+		f.fail("DeleteShader: shader %d doesn't exist", shader)
+	}
+	delete(f.shaders, shader)
+}
+
+func (f *RecordingGL) ShaderSource(shader uint32, count int32, xstring **uint8, length *int32) {
+	f.record("ShaderSource", shader, count)
+	s := f.shaders[shader]
+	if s == nil {
+		f.fail("ShaderSource: shader %d doesn't exist", shader)
+		return
+	}
+
+	strs := unsafe.Slice(xstring, count)
+	var lengths []int32
+	if length != nil {
+		lengths = unsafe.Slice(length, count)
+	}
+	var source strings.Builder
+	for i, str := range strs {
+		if lengths == nil || lengths[i] < 0 {
+			source.WriteString(gl.GoStr(str))
+		} else {
+			source.Write(unsafe.Slice(str, lengths[i]))
+		}
+	}
+	s.Source = source.String()
+}
+
+func (f *RecordingGL) CompileShader(shader uint32) {
+	f.record("CompileShader", shader)
+	s := f.shaders[shader]
+	if s == nil {
+		f.fail("CompileShader: shader %d doesn't exist", shader)
+		return
+	}
+	s.InfoLog = ""
+	if f.CompileError != nil {
+		s.InfoLog = f.CompileError(s.Type, s.Source)
+	}
+	s.Compiled = s.InfoLog == ""
+}
+
+func (f *RecordingGL) GetShaderiv(shader uint32, pname uint32, params *int32) {
+	f.record("GetShaderiv", shader, pname)
+	s := f.shaders[shader]
+	if s == nil {
+		f.fail("GetShaderiv: shader %d doesn't exist", shader)
+		return
+	}
+	switch pname {
+	case gl.COMPILE_STATUS:
+		*params = glBool(s.Compiled)
+	case gl.INFO_LOG_LENGTH:
+		*params = logLength(s.InfoLog)
+	case gl.SHADER_TYPE:
+		*params = int32(s.Type)
+	default:
+		f.fail("GetShaderiv: unsupported parameter 0x%X", pname)
+	}
+}
+
+func (f *RecordingGL) GetShaderInfoLog(shader uint32, bufSize int32, length *int32, infoLog *uint8) {
+	f.record("GetShaderInfoLog", shader, bufSize)
+	if s := f.shaders[shader]; s != nil {
+		writeGLString(s.InfoLog, bufSize, length, infoLog)
+	}
+}
+
+func (f *RecordingGL) CreateProgram() uint32 {
+	id := f.newID()
+	f.programs[id] = &FakeProgram{
+		Uniforms:         make(map[string]any),
+		BlockBindings:    make(map[string]uint32),
+		uniformLocations: make(map[string]int32),
+	}
+	f.record("CreateProgram")
+	return id
+}
+
+func (f *RecordingGL) DeleteProgram(program uint32) {
+	f.record("DeleteProgram", program)
+	if _, ok := f.programs[program]; !ok && program != 0 {
+		f.fail("DeleteProgram: program %d doesn't exist", program)
+	}
+	delete(f.programs, program)
+	if f.program == program {
+		f.program = 0
+	}
+}
+
+func (f *RecordingGL) AttachShader(program uint32, shader uint32) {
+	f.record("AttachShader", program, shader)
+	p := f.programs[program]
+	if p == nil || f.shaders[shader] == nil {
+		f.fail("AttachShader: program %d or shader %d doesn't exist", program, shader)
+		return
+	}
+	p.Shaders = append(p.Shaders, shader)
+}
+
+func (f *RecordingGL) LinkProgram(program uint32) {
+	f.record("LinkProgram", program)
+	p := f.programs[program]
+	if p == nil {
+		f.fail("LinkProgram: program %d doesn't exist", program)
+		return
+	}
+
+	var stages []fakeStage
+	for _, id := range p.Shaders {
+		s := f.shaders[id]
+		if s == nil || !s.Compiled {
+			p.Linked = false
+			p.InfoLog = fmt.Sprintf("shader %d isn't compiled", id)
+			return
+		}
+		stages = append(stages, fakeStage{s.Type, s.Source})
+	}
+	p.link(stages)
+}
+
+// a shader a program was linked from
+type fakeStage struct {
+	Type   uint32
+	Source string
+}
+
+// reflects the attributes, uniforms and uniform blocks of the stages
+func (p *FakeProgram) link(stages []fakeStage) {
+	p.Linked = true
+	p.InfoLog = ""
+	p.stages = stages
+	p.Attributes = nil
+	clear(p.Uniforms)
+	clear(p.uniformLocations)
+	p.uniforms = nil
+	p.uniformNames = nil
+	p.blockNames = nil
+
+	structs := make(map[string][]glsl.Variable)
+	var uniforms []glsl.Variable
+	for _, stage := range stages {
+		iface := glsl.ParseInterface(&glsl.Source{Source: stage.Source}, glsl.Stage(stage.Type))
+		if stage.Type == gl.VERTEX_SHADER {
+			p.Attributes = fakeAttributes(iface.Inputs)
+		}
+		maps.Copy(structs, iface.Structs)
+		uniforms = append(uniforms, iface.Uniforms...)
+	}
+
+	//uniforms used by several stages are only active once
+	declared := make(map[string]bool)
+	for _, u := range uniforms {
+		if u.Block != "" && !slices.Contains(p.blockNames, u.Block) {
+			p.blockNames = append(p.blockNames, u.Block)
+		}
+		if declared[u.Block+"."+u.Name] {
+			continue
+		}
+		declared[u.Block+"."+u.Name] = true
+		p.addUniform(u.Name, u.Type, u.Block != "", structs)
+	}
+}
+
+// adds a uniform the way gl reports it: structs are split into their members,
+// arrays of structs into their elements and arrays of anything else are
+// a single uniform named after their first element. Members of uniform
+// blocks have no location and are named without the block
+func (p *FakeProgram) addUniform(name, typeName string, inBlock bool, structs map[string][]glsl.Variable) {
+	base, sizes := splitArrayType(typeName)
+	if members, ok := structs[base]; ok {
+		for _, element := range arrayElements(name, sizes) {
+			for _, member := range members {
+				p.addUniform(element+"."+member.Name, member.Type, inBlock, structs)
+			}
+		}
+		return
+	}
+
+	size := int32(1)
+	if len(sizes) > 0 {
+		size = sizes[len(sizes)-1]
+		sizes = sizes[:len(sizes)-1]
+	}
+	isArray := strings.HasSuffix(typeName, "]")
+	for _, element := range arrayElements(name, sizes) {
+		info := UniformInfo{Name: element, Type: uniformType(base), Size: size, Location: -1}
+		if isArray {
+			info.Name += "[0]"
+		}
+		if !inBlock {
+			info.Location = int32(len(p.uniformNames))
+			for i := int32(0); i < size; i++ {
+				elementName := element
+				if isArray {
+					elementName = fmt.Sprintf("%s[%d]", element, i)
+				}
+				p.uniformLocations[elementName] = info.Location + i
+				p.uniformNames = append(p.uniformNames, elementName)
+			}
+			p.uniformLocations[element] = info.Location
+		}
+		p.uniforms = append(p.uniforms, info)
+	}
+}
+
+// the type without its array sizes and the sizes e.g. "vec3", [2 4] for "vec3[2][4]"
+// sizes that aren't numbers count as 1
+func splitArrayType(typeName string) (base string, sizes []int32) {
+	base, arrays, _ := strings.Cut(typeName, "[")
+	if arrays == "" {
+		return base, nil
+	}
+	for _, size := range strings.Split(strings.TrimSuffix(arrays, "]"), "][") {
+		n, err := strconv.Atoi(size)
+		if err != nil || n < 1 {
+			n = 1
+		}
+		sizes = append(sizes, int32(n))
+	}
+	return base, sizes
+}
+
+// every element of an array with sizes e.g. "a[0][0]", "a[0][1]"
+func arrayElements(name string, sizes []int32) []string {
+	elements := []string{name}
+	for _, size := range sizes {
+		var next []string
+		for _, element := range elements {
+			for i := int32(0); i < size; i++ {
+				next = append(next, fmt.Sprintf("%s[%d]", element, i))
+			}
+		}
+		elements = next
+	}
+	return elements
+}
+
+// the gl type of a glsl type name or 0 if it isn't known
+func uniformType(typeName string) uint32 {
+	if xtype, ok := glslTypes[typeName]; ok {
+		return xtype
+	}
+	for xtype, name := range uniformTypeNames {
+		if name == typeName {
+			return xtype
+		}
+	}
+	return 0
+}
+
+// the inputs of a vertex shader with locations for the ones without them
+func fakeAttributes(inputs []glsl.Variable) []AttributeInfo {
+	var attributes []AttributeInfo
+	used := make(map[int32]bool)
+	for _, input := range inputs {
+		typeName, size := input.Type, int32(1)
+		if base, array, ok := strings.Cut(typeName, "["); ok {
+			typeName = base
+			if n, err := strconv.Atoi(strings.TrimSuffix(array, "]")); err == nil {
+				size = int32(n)
+			}
+		}
+		xtype := glslTypes[typeName]
+		_, locations, _ := attributeShape(xtype)
+		attributes = append(attributes, AttributeInfo{
+			Name:     input.Name,
+			Type:     xtype,
+			Size:     size,
+			Location: int32(input.Location),
+		})
+		if input.Location >= 0 {
+			for i := int32(0); i < locations*size; i++ {
+				used[int32(input.Location)+i] = true
+			}
+		}
+	}
+
+	next := int32(0)
+	for i, a := range attributes {
+		if a.Location >= 0 {
+			continue
+		}
+		_, locations, _ := attributeShape(a.Type)
+		for !freeLocations(used, next, locations*a.Size) {
+			next++
+		}
+		attributes[i].Location = next
+		for j := int32(0); j < locations*a.Size; j++ {
+			used[next+j] = true
+		}
+	}
+	return attributes
+}
+
+func freeLocations(used map[int32]bool, first, count int32) bool {
+	for i := first; i < first+count; i++ {
+		if used[i] {
+			return false
+		}
+	}
+	return true
+}
+
+var glslTypes = map[string]uint32{
+	"float": gl.FLOAT, "vec2": gl.FLOAT_VEC2, "vec3": gl.FLOAT_VEC3, "vec4": gl.FLOAT_VEC4,
+	"int": gl.INT, "ivec2": gl.INT_VEC2, "ivec3": gl.INT_VEC3, "ivec4": gl.INT_VEC4,
+	"uint": gl.UNSIGNED_INT, "uvec2": gl.UNSIGNED_INT_VEC2, "uvec3": gl.UNSIGNED_INT_VEC3, "uvec4": gl.UNSIGNED_INT_VEC4,
+	"mat2": gl.FLOAT_MAT2, "mat3": gl.FLOAT_MAT3, "mat4": gl.FLOAT_MAT4,
+	"mat2x2": gl.FLOAT_MAT2, "mat3x3": gl.FLOAT_MAT3, "mat4x4": gl.FLOAT_MAT4,
+	"mat2x3": gl.FLOAT_MAT2x3, "mat2x4": gl.FLOAT_MAT2x4, "mat3x2": gl.FLOAT_MAT3x2,
+	"mat3x4": gl.FLOAT_MAT3x4, "mat4x2": gl.FLOAT_MAT4x2, "mat4x3": gl.FLOAT_MAT4x3,
+}
+
+func (f *RecordingGL) linkedProgram(function string, program uint32) *FakeProgram {
+	p := f.programs[program]
+	if p == nil || !p.Linked {
+		f.fail("%s: program %d isn't linked", function, program)
+		return nil
+	}
+	return p
+}
+
+func (f *RecordingGL) UseProgram(program uint32) {
+	f.record("UseProgram", program)
+	if program != 0 && f.linkedProgram("UseProgram", program) == nil {
+		return
+	}
+	f.program = program
+}
+
+func (f *RecordingGL) GetProgramiv(program uint32, pname uint32, params *int32) {
+	f.record("GetProgramiv", program, pname)
+	p := f.programs[program]
+	if p == nil {
+		f.fail("GetProgramiv: program %d doesn't exist", program)
+		return
+	}
+	switch pname {
+	case gl.LINK_STATUS:
+		*params = glBool(p.Linked)
+	case gl.INFO_LOG_LENGTH:
+		*params = logLength(p.InfoLog)
+	case gl.ACTIVE_ATTRIBUTES:
+		*params = int32(len(p.Attributes))
+	case gl.ACTIVE_ATTRIBUTE_MAX_LENGTH:
+		*params = 0
+		for _, a := range p.Attributes {
+			*params = max(*params, int32(len(a.Name)+1))
+		}
+	case gl.ACTIVE_UNIFORMS:
+		*params = int32(len(p.uniforms))
+	case gl.ACTIVE_UNIFORM_MAX_LENGTH:
+		*params = 0
+		for _, u := range p.uniforms {
+			*params = max(*params, int32(len(u.Name)+1))
+		}
+	case gl.ACTIVE_UNIFORM_BLOCKS:
+		*params = int32(len(p.blockNames))
+	case gl.PROGRAM_BINARY_LENGTH:
+		*params = 0
+		if p.Linked {
+			*params = int32(len(p.binary()))
+		}
+	default:
+		f.fail("GetProgramiv: unsupported parameter 0x%X", pname)
+	}
+}
+
+func (f *RecordingGL) GetProgramInfoLog(program uint32, bufSize int32, length *int32, infoLog *uint8) {
+	f.record("GetProgramInfoLog", program, bufSize)
+	if p := f.programs[program]; p != nil {
+		writeGLString(p.InfoLog, bufSize, length, infoLog)
+	}
+}
+
+func (f *RecordingGL) ProgramParameteri(program uint32, pname uint32, value int32) {
+	f.record("ProgramParameteri", program, pname, value)
+}
+
+// the only binary format there is, its binaries
+// are the type and source of each shader as json
+const fakeBinaryFormat = 0x52474C
+
+var fakeBinaryHeader = []byte("RecordingGL program\n")
+
+func (p *FakeProgram) binary() []byte {
+	stages, _ := json.Marshal(p.stages)
+	return append(slices.Clip(fakeBinaryHeader), stages...)
+}
+
+func (f *RecordingGL) GetProgramBinary(program uint32, bufSize int32, length *int32, binaryFormat *uint32, binary unsafe.Pointer) {
+	f.record("GetProgramBinary", program, bufSize)
+	if length != nil {
+		*length = 0
+	}
+	p := f.linkedProgram("GetProgramBinary", program)
+	if p == nil {
+		return
+	}
+	data := p.binary()
+	if int(bufSize) < len(data) {
+		f.fail("GetProgramBinary: the binary is %d bytes but the buffer is %d", len(data), bufSize)
+		return
+	}
+	copy(unsafe.Slice((*byte)(binary), bufSize), data)
+	*binaryFormat = fakeBinaryFormat
+	if length != nil {
+		*length = int32(len(data))
+	}
+}
+
+// binaries that weren't made by GetProgramBinary fail to link
+func (f *RecordingGL) ProgramBinary(program uint32, binaryFormat uint32, binary unsafe.Pointer, length int32) {
+	f.record("ProgramBinary", program, binaryFormat, length)
+	p := f.programs[program]
+	if p == nil {
+		f.fail("ProgramBinary: program %d doesn't exist", program)
+		return
+	}
+	p.Linked = false
+	if binaryFormat != fakeBinaryFormat {
+		f.fail("ProgramBinary: unknown binary format 0x%X", binaryFormat)
+		p.InfoLog = "unknown binary format"
+		return
+	}
+
+	data := unsafe.Slice((*byte)(binary), length)
+	var stages []fakeStage
+	source, ok := bytes.CutPrefix(data, fakeBinaryHeader)
+	if !ok || json.Unmarshal(source, &stages) != nil {
+		p.InfoLog = "invalid program binary"
+		return
+	}
+	p.link(stages)
+}
+
+func (f *RecordingGL) GetActiveAttrib(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	f.record("GetActiveAttrib", program, index, bufSize)
+	p := f.linkedProgram("GetActiveAttrib", program)
+	if p == nil {
+		return
+	}
+	if int(index) >= len(p.Attributes) {
+		f.fail("GetActiveAttrib: program %d has no attribute %d", program, index)
+		return
+	}
+	a := p.Attributes[index]
+	*size = a.Size
+	*xtype = a.Type
+	writeGLString(a.Name, bufSize, length, name)
+}
+
+func (f *RecordingGL) GetAttribLocation(program uint32, name *uint8) int32 {
+	attribute := gl.GoStr(name)
+	f.record("GetAttribLocation", program, attribute)
+	p := f.linkedProgram("GetAttribLocation", program)
+	if p == nil {
+		return -1
+	}
+	for _, a := range p.Attributes {
+		if a.Name == attribute {
+			return a.Location
+		}
+	}
+	return -1
+}
+
+func (f *RecordingGL) GetActiveUniform(program uint32, index uint32, bufSize int32, length *int32, size *int32, xtype *uint32, name *uint8) {
+	f.record("GetActiveUniform", program, index, bufSize)
+	p := f.linkedProgram("GetActiveUniform", program)
+	if p == nil {
+		return
+	}
+	if int(index) >= len(p.uniforms) {
+		f.fail("GetActiveUniform: program %d has no uniform %d", program, index)
+		return
+	}
+	u := p.uniforms[index]
+	*size = u.Size
+	*xtype = u.Type
+	writeGLString(u.Name, bufSize, length, name)
+}
+
+// every element of an array has a location and the
+// array's name is the location of its first element
+func (f *RecordingGL) GetUniformLocation(program uint32, name *uint8) int32 {
+	uniform := gl.GoStr(name)
+	f.record("GetUniformLocation", program, uniform)
+	p := f.linkedProgram("GetUniformLocation", program)
+	if p == nil {
+		return -1
+	}
+	if loc, ok := p.uniformLocations[uniform]; ok {
+		return loc
+	}
+	return -1
+}
+
+func (f *RecordingGL) GetUniformBlockIndex(program uint32, uniformBlockName *uint8) uint32 {
+	block := gl.GoStr(uniformBlockName)
+	f.record("GetUniformBlockIndex", program, block)
+	p := f.linkedProgram("GetUniformBlockIndex", program)
+	if p == nil {
+		return gl.INVALID_INDEX
+	}
+	if i := slices.Index(p.blockNames, block); i >= 0 {
+		return uint32(i)
+	}
+	return gl.INVALID_INDEX
+}
+
+func (f *RecordingGL) UniformBlockBinding(program uint32, uniformBlockIndex uint32, uniformBlockBinding uint32) {
+	f.record("UniformBlockBinding", program, uniformBlockIndex, uniformBlockBinding)
+	p := f.linkedProgram("UniformBlockBinding", program)
+	if p == nil {
+		return
+	}
+	if int(uniformBlockIndex) >= len(p.blockNames) {
+		f.fail("UniformBlockBinding: program %d has no block %d", program, uniformBlockIndex)
+		return
+	}
+	p.BlockBindings[p.blockNames[uniformBlockIndex]] = uniformBlockBinding
+}
+
+// uniforms
+
+// stores the value of the uniform at location in the current program
+func (f *RecordingGL) setUniform(function string, location int32, value any) {
+	f.record(function, location, value)
+	if location < 0 {
+		return
+	}
+	p := f.linkedProgram(function, f.program)
+	if p == nil {
+		return
+	}
+	if int(location) >= len(p.uniformNames) {
+		f.fail("%s: the current program has no uniform at location %d", function, location)
+		return
+	}
+	p.Uniforms[p.uniformNames[location]] = value
+}
+
+func (f *RecordingGL) Uniform1f(location int32, v0 float32) {
+	f.setUniform("Uniform1f", location, []float32{v0})
+}
+
+func (f *RecordingGL) Uniform1fv(location int32, count int32, value *float32) {
+	f.setUniform("Uniform1fv", location, copyValues(value, count))
+}
+
+func (f *RecordingGL) Uniform1i(location int32, v0 int32) {
+	f.setUniform("Uniform1i", location, []int32{v0})
+}
+
+func (f *RecordingGL) Uniform1iv(location int32, count int32, value *int32) {
+	f.setUniform("Uniform1iv", location, copyValues(value, count))
+}
+
+func (f *RecordingGL) Uniform1ui(location int32, v0 uint32) {
+	f.setUniform("Uniform1ui", location, []uint32{v0})
+}
+
+func (f *RecordingGL) Uniform1uiv(location int32, count int32, value *uint32) {
+	f.setUniform("Uniform1uiv", location, copyValues(value, count))
+}
+
+func (f *RecordingGL) Uniform2f(location int32, v0 float32, v1 float32) {
+	f.setUniform("Uniform2f", location, []float32{v0, v1})
+}
+
+func (f *RecordingGL) Uniform2fv(location int32, count int32, value *float32) {
+	f.setUniform("Uniform2fv", location, copyValues(value, count*2))
+}
+
+func (f *RecordingGL) Uniform2i(location int32, v0 int32, v1 int32) {
+	f.setUniform("Uniform2i", location, []int32{v0, v1})
+}
+
+func (f *RecordingGL) Uniform2iv(location int32, count int32, value *int32) {
+	f.setUniform("Uniform2iv", location, copyValues(value, count*2))
+}
+
+func (f *RecordingGL) Uniform2ui(location int32, v0 uint32, v1 uint32) {
+	f.setUniform("Uniform2ui", location, []uint32{v0, v1})
+}
+
+func (f *RecordingGL) Uniform2uiv(location int32, count int32, value *uint32) {
+	f.setUniform("Uniform2uiv", location, copyValues(value, count*2))
+}
+
+func (f *RecordingGL) Uniform3fv(location int32, count int32, value *float32) {
+	f.setUniform("Uniform3fv", location, copyValues(value, count*3))
+}
+
+func (f *RecordingGL) Uniform3i(location int32, v0 int32, v1 int32, v2 int32) {
+	f.setUniform("Uniform3i", location, []int32{v0, v1, v2})
+}
+
+func (f *RecordingGL) Uniform3iv(location int32, count int32, value *int32) {
+	f.setUniform("Uniform3iv", location, copyValues(value, count*3))
+}
+
+func (f *RecordingGL) Uniform3ui(location int32, v0 uint32, v1 uint32, v2 uint32) {
+	f.setUniform("Uniform3ui", location, []uint32{v0, v1, v2})
+}
+
+func (f *RecordingGL) Uniform3uiv(location int32, count int32, value *uint32) {
+	f.setUniform("Uniform3uiv", location, copyValues(value, count*3))
+}
+
+func (f *RecordingGL) Uniform4f(location int32, v0 float32, v1 float32, v2 float32, v3 float32) {
+	f.setUniform("Uniform4f", location, []float32{v0, v1, v2, v3})
+}
+
+func (f *RecordingGL) Uniform4fv(location int32, count int32, value *float32) {
+	f.setUniform("Uniform4fv", location, copyValues(value, count*4))
+}
+
+func (f *RecordingGL) Uniform4i(location int32, v0 int32, v1 int32, v2 int32, v3 int32) {
+	f.setUniform("Uniform4i", location, []int32{v0, v1, v2, v3})
+}
+
+func (f *RecordingGL) Uniform4iv(location int32, count int32, value *int32) {
+	f.setUniform("Uniform4iv", location, copyValues(value, count*4))
+}
+
+func (f *RecordingGL) Uniform4ui(location int32, v0 uint32, v1 uint32, v2 uint32, v3 uint32) {
+	f.setUniform("Uniform4ui", location, []uint32{v0, v1, v2, v3})
+}
+
+func (f *RecordingGL) Uniform4uiv(location int32, count int32, value *uint32) {
+	f.setUniform("Uniform4uiv", location, copyValues(value, count*4))
+}
+
+func (f *RecordingGL) UniformMatrix2fv(location int32, count int32, transpose bool, value *float32) {
+	f.setUniform("UniformMatrix2fv", location, copyValues(value, count*4))
+}
+
+func (f *RecordingGL) UniformMatrix3fv(location int32, count int32, transpose bool, value *float32) {
+	f.setUniform("UniformMatrix3fv", location, copyValues(value, count*9))
+}
+
+func (f *RecordingGL) UniformMatrix4fv(location int32, count int32, transpose bool, value *float32) {
+	f.setUniform("UniformMatrix4fv", location, copyValues(value, count*16))
+}
+
+func copyValues[T any](values *T, n int32) []T {
+	return append([]T(nil), unsafe.Slice(values, n)...)
+}
+
+// framebuffers
+
+func (f *RecordingGL) GenFramebuffers(n int32, framebuffers *uint32) {
+	ids := unsafe.Slice(framebuffers, n)
+	for i := range ids {
+		ids[i] = f.newID()
+		f.framebuffers[ids[i]] = &FakeFramebuffer{Attachments: make(map[uint32]uint32)}
+	}
+	f.record("GenFramebuffers", n, append([]uint32(nil), ids...))
+}
+
+func (f *RecordingGL) DeleteFramebuffers(n int32, framebuffers *uint32) {
+	ids := unsafe.Slice(framebuffers, n)
+	f.record("DeleteFramebuffers", n, append([]uint32(nil), ids...))
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := f.framebuffers[id]; !ok {
+			f.fail("DeleteFramebuffers: framebuffer %d doesn't exist", id)
+		}
+		delete(f.framebuffers, id)
+		if f.framebuffer == id {
+			f.framebuffer = 0
+		}
+	}
+}
+
+// read and draw framebuffers aren't told apart
+func (f *RecordingGL) BindFramebuffer(target uint32, framebuffer uint32) {
+	f.record("BindFramebuffer", target, framebuffer)
+	if _, ok := f.framebuffers[framebuffer]; !ok && framebuffer != 0 {
+		f.fail("BindFramebuffer: framebuffer %d doesn't exist", framebuffer)
+		return
+	}
+	f.framebuffer = framebuffer
+}
+
+func (f *RecordingGL) FramebufferRenderbuffer(target uint32, attachment uint32, renderbuffertarget uint32, renderbuffer uint32) {
+	f.record("FramebufferRenderbuffer", target, attachment, renderbuffertarget, renderbuffer)
+	fb := f.framebuffers[f.framebuffer]
+	if fb == nil {
+		f.fail("FramebufferRenderbuffer: no framebuffer is bound")
+		return
+	}
+	if _, ok := f.renderbuffers[renderbuffer]; !ok && renderbuffer != 0 {
+		f.fail("FramebufferRenderbuffer: renderbuffer %d doesn't exist", renderbuffer)
+		return
+	}
+	fb.Attachments[attachment] = renderbuffer
+}
+
+// complete when the bound framebuffer has a color attachment
+// and all its attachments are the same size
+func (f *RecordingGL) CheckFramebufferStatus(target uint32) uint32 {
+	f.record("CheckFramebufferStatus", target)
+	fb := f.framebuffers[f.framebuffer]
+	if fb == nil {
+		return gl.FRAMEBUFFER_COMPLETE //the default framebuffer
+	}
+	if fb.Attachments[gl.COLOR_ATTACHMENT0] == 0 {
+		return gl.FRAMEBUFFER_INCOMPLETE_MISSING_ATTACHMENT
+	}
+	color := f.renderbuffers[fb.Attachments[gl.COLOR_ATTACHMENT0]]
+	for _, id := range fb.Attachments {
+		rb := f.renderbuffers[id]
+		if rb == nil || rb.Width == 0 || rb.Height == 0 {
+			return gl.FRAMEBUFFER_INCOMPLETE_ATTACHMENT
+		}
+		if rb.Width != color.Width || rb.Height != color.Height {
+			return gl.FRAMEBUFFER_UNSUPPORTED
+		}
+	}
+	return gl.FRAMEBUFFER_COMPLETE
+}
+
+func (f *RecordingGL) GenRenderbuffers(n int32, renderbuffers *uint32) {
+	ids := unsafe.Slice(renderbuffers, n)
+	for i := range ids {
+		ids[i] = f.newID()
+		f.renderbuffers[ids[i]] = &FakeRenderbuffer{}
+	}
+	f.record("GenRenderbuffers", n, append([]uint32(nil), ids...))
+}
+
+func (f *RecordingGL) DeleteRenderbuffers(n int32, renderbuffers *uint32) {
+	ids := unsafe.Slice(renderbuffers, n)
+	f.record("DeleteRenderbuffers", n, append([]uint32(nil), ids...))
+	for _, id := range ids {
+		if id == 0 {
+			continue
+		}
+		if _, ok := f.renderbuffers[id]; !ok {
+			f.fail("DeleteRenderbuffers: renderbuffer %d doesn't exist", id)
+		}
+		delete(f.renderbuffers, id)
+		if f.renderbuffer == id {
+			f.renderbuffer = 0
+		}
+	}
+}
+
+func (f *RecordingGL) BindRenderbuffer(target uint32, renderbuffer uint32) {
+	f.record("BindRenderbuffer", target, renderbuffer)
+	if _, ok := f.renderbuffers[renderbuffer]; !ok && renderbuffer != 0 {
+		f.fail("BindRenderbuffer: renderbuffer %d doesn't exist", renderbuffer)
+		return
+	}
+	f.renderbuffer = renderbuffer
+}
+
+func (f *RecordingGL) RenderbufferStorage(target uint32, internalformat uint32, width int32, height int32) {
+	f.record("RenderbufferStorage", target, internalformat, width, height)
+	rb := f.renderbuffers[f.renderbuffer]
+	if rb == nil {
+		f.fail("RenderbufferStorage: no renderbuffer is bound")
+		return
+	}
+	rb.InternalFormat = internalformat
+	rb.Width, rb.Height = width, height
+}
+
+// nothing is rasterized so the pixels read are always zero
+func (f *RecordingGL) ReadPixels(x int32, y int32, width int32, height int32, format uint32, xtype uint32, pixels unsafe.Pointer) {
+	f.record("ReadPixels", x, y, width, height, format, xtype)
+	clear(unsafe.Slice((*byte)(pixels), int(width)*int(height)*pixelSize(format, xtype)))
+}
+
+// state
+
+func (f *RecordingGL) Enable(capability uint32) {
+	f.record("Enable", capability)
+	f.enabled[capability] = true
+}
+
+func (f *RecordingGL) Viewport(x int32, y int32, width int32, height int32) {
+	f.record("Viewport", x, y, width, height)
+	f.viewport = [4]int32{x, y, width, height}
+}
+
+func (f *RecordingGL) GetIntegerv(pname uint32, data *int32) {
+	f.record("GetIntegerv", pname)
+	switch pname {
+	case gl.ARRAY_BUFFER_BINDING:
+		*data = int32(f.bufferBinding(gl.ARRAY_BUFFER))
+	case gl.ELEMENT_ARRAY_BUFFER_BINDING:
+		*data = int32(f.bufferBinding(gl.ELEMENT_ARRAY_BUFFER))
+	case gl.VERTEX_ARRAY_BINDING:
+		*data = int32(f.vertexArray)
+	case gl.TEXTURE_BINDING_2D:
+		*data = int32(f.textureBinding[gl.TEXTURE_2D])
+	case gl.CURRENT_PROGRAM:
+		*data = int32(f.program)
+	case gl.FRAMEBUFFER_BINDING:
+		*data = int32(f.framebuffer)
+	case gl.VIEWPORT:
+		copy(unsafe.Slice(data, 4), f.viewport[:])
+	case gl.MAJOR_VERSION, gl.MINOR_VERSION:
+		*data = 3
+	case gl.NUM_EXTENSIONS:
+		*data = int32(len(f.extensions))
+	case gl.NUM_PROGRAM_BINARY_FORMATS:
+		*data = 1
+	case gl.PROGRAM_BINARY_FORMATS:
+		*data = fakeBinaryFormat
+	default:
+		f.fail("GetIntegerv: unsupported parameter 0x%X", pname)
+	}
+}
+
+func (f *RecordingGL) GetString(name uint32) *uint8 {
+	f.record("GetString", name)
+	var value string
+	switch name {
+	case gl.VERSION:
+		value = "3.3.0 RecordingGL"
+	case gl.SHADING_LANGUAGE_VERSION:
+		value = "3.30"
+	case gl.RENDERER:
+		value = "RecordingGL"
+	case gl.VENDOR:
+		value = "gogl"
+	default:
+		f.fail("GetString: unsupported name 0x%X", name)
+		return nil
+	}
+	//kept so the pointer stays valid like gl's static strings
+	if _, ok := f.strings[name]; !ok {
+		f.strings[name] = append([]byte(value), 0)
+	}
+	return &f.strings[name][0]
+}
+
+// the only extension is GL_ARB_get_program_binary
+func (f *RecordingGL) GetStringi(name uint32, index uint32) *uint8 {
+	f.record("GetStringi", name, index)
+	if name != gl.EXTENSIONS {
+		f.fail("GetStringi: unsupported name 0x%X", name)
+		return nil
+	}
+	if int(index) >= len(f.extensions) {
+		f.fail("GetStringi: index %d is out of range", index)
+		return nil
+	}
+	return &f.extensions[index][0]
+}
+
+func glBool(b bool) int32 {
+	if b {
+		return gl.TRUE
+	}
+	return gl.FALSE
+}
+
+// gl counts the null terminator in log lengths
+func logLength(log string) int32 {
+	if log == "" {
+		return 0
+	}
+	return int32(len(log) + 1)
+}
+
+// copies str into a buffer of bufSize bytes the way gl returns strings
+func writeGLString(str string, bufSize int32, length *int32, buf *uint8) {
+	if bufSize <= 0 || buf == nil {
+		return
+	}
+	out := unsafe.Slice(buf, bufSize)
+	n := copy(out[:bufSize-1], str)
+	out[n] = 0
+	if length != nil {
+		*length = int32(n)
+	}
+}
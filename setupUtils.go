@@ -1,38 +1,319 @@
 package gogl
 
 import (
+	"errors"
+	"fmt"
+	"os"
+
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+type GLProfile int
+
+const (
+	ProfileCore GLProfile = iota
+	ProfileCompatibility
+	// the bindings are for desktop gl so this only works with drivers that happen to accept it
+	ProfileES
+)
+
+type WindowMode int
+
+const (
+	Windowed WindowMode = iota
+	// changes the display mode to the size of the window
+	Fullscreen
+	// covers the whole desktop at its current resolution
+	FullscreenDesktop
+	Borderless
+)
+
+type VSync int
+
+const (
+	// leaves the swap interval to the driver
+	VSyncDefault VSync = iota
+	VSyncOff
+	VSyncOn
+	// tears instead of waiting when a frame is late,
+	// falls back to VSyncOn if the driver can't do it
+	VSyncAdaptive
+)
+
+// how SetupWindow creates the window and its openGL context. Fields left
+// at their zero value get sdl's or the driver's default unless said otherwise
+type WindowOptions struct {
+	Title         string
+	Width, Height int32
+	// sdl.WINDOWPOS_CENTERED and sdl.WINDOWPOS_UNDEFINED work too
+	X, Y int32
+	// the sdl subsystems to start, sdl.INIT_VIDEO if 0
+	InitFlags uint32
+
+	// the openGL version, 3.3 if 0. Lower versions can't be used with these bindings
+	GLMajor, GLMinor int
+	Profile          GLProfile
+	// asks for a context that checks calls and reports more errors
+	Debug bool
+
+	// the number of samples per pixel for multisampling, 0 disables it
+	Samples     int
+	DepthBits   int
+	StencilBits int
+	// asks for a framebuffer that can convert to sRGB, enable
+	// gl.FRAMEBUFFER_SRGB in EnableCaps for it to be used
+	SRGB  bool
+	VSync VSync
+
+	Mode      WindowMode
+	Resizable bool
+	// uses the full resolution of high dpi displays, the drawable size
+	// from window.GLGetDrawableSize can then be bigger than Width and Height
+	HighDPI bool
+	Hidden  bool
+
+	// gl capabilities enabled once the context is made e.g. gl.DEPTH_TEST
+	EnableCaps []uint32
+	// captures the mouse and reports its movement, like in an fps game
+	RelativeMouse bool
+}
+
+// the options SetupFPSWindow uses
+func DefaultWindowOptions(title string, width, height int32) WindowOptions {
+	return WindowOptions{
+		Title:         title,
+		Width:         width,
+		Height:        height,
+		X:             sdl.WINDOWPOS_CENTERED,
+		Y:             sdl.WINDOWPOS_CENTERED,
+		InitFlags:     sdl.INIT_EVERYTHING,
+		GLMajor:       3,
+		GLMinor:       3,
+		Profile:       ProfileCore,
+		Resizable:     true,
+		EnableCaps:    []uint32{gl.DEPTH_TEST, gl.CULL_FACE},
+		RelativeMouse: true,
+	}
+}
+
+// sets up a window with openGL and sdl as described by opts
+// cleanup deletes the context, destroys the window and quits sdl
+func SetupWindow(opts WindowOptions) (window *sdl.Window, cleanup func(), err error) {
+	if opts.GLMajor == 0 {
+		opts.GLMajor, opts.GLMinor = 3, 3
+	}
+	if opts.GLMajor < 3 || (opts.GLMajor == 3 && opts.GLMinor < 3) {
+		return nil, nil, fmt.Errorf("openGL %d.%d is too old, at least 3.3 is needed", opts.GLMajor, opts.GLMinor)
+	}
+	if opts.InitFlags == 0 {
+		opts.InitFlags = sdl.INIT_VIDEO
+	}
+	flags, err := opts.windowFlags()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = sdl.Init(opts.InitFlags)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = opts.setGLAttributes(); err != nil {
+		sdl.Quit()
+		return nil, nil, err
+	}
+
+	window, err = sdl.CreateWindow(opts.Title, opts.X, opts.Y, opts.Width, opts.Height, flags)
+	if err != nil {
+		sdl.Quit()
+		return nil, nil, err
+	}
+	context, err := window.GLCreateContext()
+	if err != nil {
+		window.Destroy()
+		sdl.Quit()
+		return nil, nil, err
+	}
+	cleanup = func() {
+		sdl.GLDeleteContext(context)
+		window.Destroy()
+		sdl.Quit()
+	}
+
+	//loaded through sdl as the context might be EGL rather than GLX
+	err = gl.InitWithProcAddrFunc(sdl.GLGetProcAddress)
+	if err == nil {
+		err = setSwapInterval(opts.VSync)
+	}
+	if err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	for _, capability := range opts.EnableCaps {
+		ogl.Enable(capability)
+	}
+	if opts.RelativeMouse {
+		sdl.SetRelativeMouseMode(true)
+	}
+
+	return window, cleanup, nil
+}
+
+func MustSetupWindow(opts WindowOptions) (window *sdl.Window, cleanup func()) {
+	window, cleanup, err := SetupWindow(opts)
+	if err != nil {
+		panic(err)
+	}
+	return window, cleanup
+}
+
+func (opts WindowOptions) windowFlags() (uint32, error) {
+	var flags uint32 = sdl.WINDOW_OPENGL
+	switch opts.Mode {
+	case Windowed:
+	case Fullscreen:
+		flags |= sdl.WINDOW_FULLSCREEN
+	case FullscreenDesktop:
+		flags |= sdl.WINDOW_FULLSCREEN_DESKTOP
+	case Borderless:
+		flags |= sdl.WINDOW_BORDERLESS
+	default:
+		return 0, fmt.Errorf("unknown window mode %d", opts.Mode)
+	}
+	if opts.Resizable {
+		flags |= sdl.WINDOW_RESIZABLE
+	}
+	if opts.HighDPI {
+		flags |= sdl.WINDOW_ALLOW_HIGHDPI
+	}
+	if opts.Hidden {
+		flags |= sdl.WINDOW_HIDDEN
+	}
+	return flags, nil
+}
+
+// the attributes have to be set before the window is created
+func (opts WindowOptions) setGLAttributes() error {
+	var profile int
+	switch opts.Profile {
+	case ProfileCore:
+		profile = sdl.GL_CONTEXT_PROFILE_CORE
+	case ProfileCompatibility:
+		profile = sdl.GL_CONTEXT_PROFILE_COMPATIBILITY
+	case ProfileES:
+		profile = sdl.GL_CONTEXT_PROFILE_ES
+	default:
+		return fmt.Errorf("unknown openGL profile %d", opts.Profile)
+	}
+
+	var contextFlags int
+	if opts.Debug {
+		contextFlags |= sdl.GL_CONTEXT_DEBUG_FLAG
+	}
+
+	attributes := []struct {
+		attr  sdl.GLattr
+		value int
+		set   bool
+	}{
+		{sdl.GL_CONTEXT_PROFILE_MASK, profile, true},
+		{sdl.GL_CONTEXT_MAJOR_VERSION, opts.GLMajor, true},
+		{sdl.GL_CONTEXT_MINOR_VERSION, opts.GLMinor, true},
+		{sdl.GL_CONTEXT_FLAGS, contextFlags, opts.Debug},
+		{sdl.GL_MULTISAMPLEBUFFERS, 1, opts.Samples > 0},
+		{sdl.GL_MULTISAMPLESAMPLES, opts.Samples, opts.Samples > 0},
+		{sdl.GL_DEPTH_SIZE, opts.DepthBits, opts.DepthBits > 0},
+		{sdl.GL_STENCIL_SIZE, opts.StencilBits, opts.StencilBits > 0},
+		{sdl.GL_FRAMEBUFFER_SRGB_CAPABLE, 1, opts.SRGB},
+	}
+	var errs []error
+	for _, a := range attributes {
+		if !a.set {
+			continue
+		}
+		if err := sdl.GLSetAttribute(a.attr, a.value); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func setSwapInterval(vsync VSync) error {
+	switch vsync {
+	case VSyncDefault:
+		return nil
+	case VSyncOff:
+		return sdl.GLSetSwapInterval(0)
+	case VSyncOn:
+		return sdl.GLSetSwapInterval(1)
+	case VSyncAdaptive:
+		if sdl.GLSetSwapInterval(-1) != nil {
+			return sdl.GLSetSwapInterval(1)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown vsync mode %d", vsync)
+}
+
 // sets up a window with openGL and sdl
 // this window behaves like an fps game where
 // the mouse is in relative mode
 func SetupFPSWindow(title string, width, height int32) (window *sdl.Window, cleanup func()) {
-	err := sdl.Init(sdl.INIT_EVERYTHING)
+	window, cleanup, err := SetupFPSWindowErr(title, width, height)
 	if err != nil {
 		panic(err)
 	}
+	return window, cleanup
+}
 
-	sdl.GLSetAttribute(sdl.GL_CONTEXT_PROFILE_MASK, sdl.GL_CONTEXT_PROFILE_CORE)
-	sdl.GLSetAttribute(sdl.GL_CONTEXT_MAJOR_VERSION, 3)
-	sdl.GLSetAttribute(sdl.GL_CONTEXT_MINOR_VERSION, 3)
+// same as SetupFPSWindow but returns the error rather than panicking
+func SetupFPSWindowErr(title string, width, height int32) (window *sdl.Window, cleanup func(), err error) {
+	return SetupWindow(DefaultWindowOptions(title, width, height))
+}
 
-	window, err = sdl.CreateWindow(title, sdl.WINDOWPOS_CENTERED, sdl.WINDOWPOS_CENTERED, width, height, sdl.WINDOW_OPENGL|sdl.WINDOW_RESIZABLE)
+// sets up an openGL context with no visible window for rendering thumbnails
+// or for tests on servers. Everything is drawn into the returned framebuffer
+// which can be read back with ReadImage, the mouse is left alone
+//
+// without a display, e.g. with no X server, sdl's offscreen video driver is
+// used which renders through EGL so it works with Mesa's software rasterizer
+func SetupHeadless(width, height int32) (framebuffer *Framebuffer, cleanup func(), err error) {
+	opts := WindowOptions{
+		Width:      width,
+		Height:     height,
+		X:          sdl.WINDOWPOS_UNDEFINED,
+		Y:          sdl.WINDOWPOS_UNDEFINED,
+		Hidden:     true,
+		EnableCaps: []uint32{gl.DEPTH_TEST, gl.CULL_FACE},
+	}
+	_, destroy, err := SetupWindow(opts)
+	if err != nil && os.Getenv("SDL_VIDEODRIVER") == "" {
+		sdl.SetHint("SDL_VIDEODRIVER", "offscreen")
+		_, destroy, err = SetupWindow(opts)
+	}
 	if err != nil {
-		panic(err)
+		return nil, nil, err
 	}
-	window.GLCreateContext()
 
-	gl.Init()
-	gl.Enable(gl.DEPTH_TEST)
-	sdl.SetRelativeMouseMode(true)
-	gl.Enable(gl.CULL_FACE)
+	//a hidden window's own framebuffer may never be drawn to so a framebuffer object is used instead
+	framebuffer, err = NewFramebuffer(width, height)
+	if err != nil {
+		destroy()
+		return nil, nil, err
+	}
 
 	cleanup = func() {
-		sdl.Quit()
-		window.Destroy()
+		framebuffer.Delete()
+		destroy()
 	}
+	return framebuffer, cleanup, nil
+}
 
-	return
+func MustSetupHeadless(width, height int32) (framebuffer *Framebuffer, cleanup func()) {
+	framebuffer, cleanup, err := SetupHeadless(width, height)
+	if err != nil {
+		panic(err)
+	}
+	return framebuffer, cleanup
 }
@@ -0,0 +1,73 @@
+package gogl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestDefaultWindowOptions(t *testing.T) {
+	opts := DefaultWindowOptions("game", 800, 600)
+	if opts.Title != "game" || opts.Width != 800 || opts.Height != 600 {
+		t.Errorf("window is %q at %dx%d", opts.Title, opts.Width, opts.Height)
+	}
+	if opts.GLMajor != 3 || opts.GLMinor != 3 || opts.Profile != ProfileCore {
+		t.Errorf("context is %d.%d with profile %d, want a 3.3 core context", opts.GLMajor, opts.GLMinor, opts.Profile)
+	}
+	if !slices.Equal(opts.EnableCaps, []uint32{gl.DEPTH_TEST, gl.CULL_FACE}) || !opts.RelativeMouse {
+		t.Errorf("caps %v and relative mouse %v aren't SetupFPSWindow's", opts.EnableCaps, opts.RelativeMouse)
+	}
+
+	flags, err := opts.windowFlags()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if flags != sdl.WINDOW_OPENGL|sdl.WINDOW_RESIZABLE {
+		t.Errorf("flags = 0x%X, want a resizable openGL window", flags)
+	}
+}
+
+func TestWindowFlags(t *testing.T) {
+	tests := []struct {
+		opts WindowOptions
+		want uint32
+	}{
+		{WindowOptions{}, sdl.WINDOW_OPENGL},
+		{WindowOptions{Mode: Fullscreen}, sdl.WINDOW_OPENGL | sdl.WINDOW_FULLSCREEN},
+		{WindowOptions{Mode: FullscreenDesktop, HighDPI: true}, sdl.WINDOW_OPENGL | sdl.WINDOW_FULLSCREEN_DESKTOP | sdl.WINDOW_ALLOW_HIGHDPI},
+		{WindowOptions{Mode: Borderless, Hidden: true}, sdl.WINDOW_OPENGL | sdl.WINDOW_BORDERLESS | sdl.WINDOW_HIDDEN},
+	}
+	for _, test := range tests {
+		if got, err := test.opts.windowFlags(); err != nil || got != test.want {
+			t.Errorf("flags for %+v = 0x%X, %v, want 0x%X", test.opts, got, err, test.want)
+		}
+	}
+
+	if _, err := (WindowOptions{Mode: 7}).windowFlags(); err == nil || !strings.Contains(err.Error(), "unknown window mode 7") {
+		t.Errorf("got error %v for an unknown mode", err)
+	}
+}
+
+// these fail before sdl is started so they don't need a display
+func TestSetupWindowInvalidOptions(t *testing.T) {
+	tests := []struct {
+		opts      WindowOptions
+		wantError string
+	}{
+		{WindowOptions{GLMajor: 2, GLMinor: 1}, "openGL 2.1 is too old"},
+		{WindowOptions{GLMajor: 3, GLMinor: 2}, "openGL 3.2 is too old"},
+		{WindowOptions{Mode: -1}, "unknown window mode -1"},
+	}
+	for _, test := range tests {
+		window, cleanup, err := SetupWindow(test.opts)
+		if err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Errorf("got error %v, want %q", err, test.wantError)
+		}
+		if window != nil || cleanup != nil {
+			t.Error("a failed setup returned a window or cleanup")
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package gogl
+
+import (
+	"io/fs"
+
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+// a shader after all of its #include directives have been expanded
+// the index of a file in Files is the source string number
+// used for it in the #line directives of Source
+type ShaderSource = glsl.Source
+
+// expands every #include "file" in the shader at path
+// included paths are relative to the file that includes them
+// files with #pragma once or an include guard are only included once
+func PreprocessShader(path string) (*ShaderSource, error) {
+	return glsl.Preprocess(path)
+}
+
+// same as PreprocessShader but reads the shader and its includes from fsys
+func PreprocessShaderFS(fsys fs.FS, path string) (*ShaderSource, error) {
+	return glsl.PreprocessFS(fsys, path)
+}
@@ -0,0 +1,146 @@
+package gogl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+// macros injected after the #version line of every stage of a shader
+// an empty value gives a plain #define NAME
+// e.g. Defines{"NORMAL_MAP": "", "NUM_LIGHTS": "4"}
+type Defines map[string]string
+
+func (d Defines) validate() error {
+	return glsl.ValidateDefines(d)
+}
+
+func (d Defines) names() []string {
+	names := make([]string, 0, len(d))
+	for name := range d {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the same set of defines always gives the same key
+// no matter what order they were added in. Valid values can't
+// contain a line break so it can't be mixed up with the next define
+func (d Defines) key() string {
+	var key strings.Builder
+	for _, name := range d.names() {
+		key.WriteString(name)
+		if value := d[name]; value != "" {
+			key.WriteString("=" + value)
+		}
+		key.WriteString("\n")
+	}
+	return key.String()
+}
+
+// a copy of d with every define in other added to it
+func (d Defines) merge(other Defines) Defines {
+	merged := make(Defines, len(d)+len(other))
+	for name, value := range d {
+		merged[name] = value
+	}
+	for name, value := range other {
+		merged[name] = value
+	}
+	return merged
+}
+
+// adds a #define for each of the defines after the #version line of source
+// a #line directive after them keeps the line numbers in compile logs correct
+// the names have to be identifiers and the values can't contain a line break
+func InjectDefines(source string, defines Defines) (string, error) {
+	return glsl.InjectDefines(source, defines)
+}
+
+// a cache of the programs built from the same shader
+// with different sets of defines
+type ShaderVariants struct {
+	build    func(defines Defines) (Shader, error)
+	base     Shader
+	variants map[string]Shader
+}
+
+// variants of a file based shader, each of them hot reloads
+// when any of the files it was built from changes
+func NewShaderVariants(base *ShaderWithPaths) *ShaderVariants {
+	v := ShaderVariants{
+		build: func(defines Defines) (Shader, error) {
+			return base.WithDefines(defines)
+		},
+		base:     base,
+		variants: make(map[string]Shader),
+	}
+	v.variants[Defines{}.key()] = base
+	return &v
+}
+
+func NewEmbeddedShaderVariants(base *EmbeddedShader) *ShaderVariants {
+	v := ShaderVariants{
+		build: func(defines Defines) (Shader, error) {
+			return base.WithDefines(defines)
+		},
+		base:     base,
+		variants: make(map[string]Shader),
+	}
+	v.variants[Defines{}.key()] = base
+	return &v
+}
+
+// the variant built with defines, it is only compiled
+// the first time a set of defines is asked for
+func (v *ShaderVariants) Get(defines Defines) (Shader, error) {
+	if err := defines.validate(); err != nil {
+		return nil, err
+	}
+	key := defines.key()
+	if shader, ok := v.variants[key]; ok {
+		return shader, nil
+	}
+
+	shader, err := v.build(defines)
+	if err != nil {
+		return nil, err
+	}
+	v.variants[key] = shader
+	return shader, nil
+}
+
+func (v *ShaderVariants) MustGet(defines Defines) Shader {
+	shader, err := v.Get(defines)
+	if err != nil {
+		panic(err)
+	}
+	return shader
+}
+
+// the number of variants that have been built
+func (v *ShaderVariants) Len() int {
+	return len(v.variants)
+}
+
+// checks every cached variant so an edit to a
+// shared source file reloads all of them
+func (v *ShaderVariants) CheckShadersForChanges() {
+	for _, shader := range v.variants {
+		shader.CheckShadersForChanges()
+	}
+}
+
+// deletes every variant built by Get, the base shader
+// still belongs to the caller and is left alone
+func (v *ShaderVariants) Delete() {
+	for key, shader := range v.variants {
+		if shader == v.base {
+			continue
+		}
+		shader.Delete()
+		delete(v.variants, key)
+	}
+}
@@ -0,0 +1,79 @@
+package gogl
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestShaderVariantsInvalidDefines(t *testing.T) {
+	_, restore := UseRecordingGL()
+	defer restore()
+
+	base, err := NewEmbeddedShaderErr(testVertexShader, testFragmentShader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer base.Delete()
+	variants := NewEmbeddedShaderVariants(base)
+	defer variants.Delete()
+
+	invalid := []Defines{
+		{"NUM LIGHTS": "4"},
+		{"NUM_LIGHTS": "4\nuniform float injected;"},
+	}
+	for _, defines := range invalid {
+		if _, err := variants.Get(defines); err == nil {
+			t.Errorf("%q should be invalid", defines)
+		}
+	}
+	if variants.Len() != 1 {
+		t.Errorf("invalid defines were cached, Len = %d", variants.Len())
+	}
+}
+
+func TestShaderVariantsDeleteKeepsBase(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	defer restore()
+
+	base, err := NewEmbeddedShaderErr(testVertexShader, testFragmentShader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	variants := NewEmbeddedShaderVariants(base)
+
+	if shader, _ := variants.Get(nil); shader != base {
+		t.Error("no defines should give the base shader")
+	}
+	normalMap, err := variants.Get(Defines{"NORMAL_MAP": ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again, _ := variants.Get(Defines{"NORMAL_MAP": ""}); again != normalMap {
+		t.Error("the same defines should give the cached variant")
+	}
+	if variants.Len() != 2 {
+		t.Errorf("Len = %d, want 2", variants.Len())
+	}
+
+	variant := normalMap.(*EmbeddedShader).id
+	variants.Delete()
+
+	live := fake.Live(ResourceProgram)
+	if !slices.Contains(live, uint32(base.id)) {
+		t.Error("Delete deleted the base shader the caller owns")
+	}
+	if slices.Contains(live, uint32(variant)) {
+		t.Error("Delete left the variant it built")
+	}
+	if shader, _ := variants.Get(nil); shader != base {
+		t.Error("the base shader should still be returned after Delete")
+	}
+	if variants.Len() != 1 {
+		t.Errorf("Len after Delete = %d, want 1", variants.Len())
+	}
+
+	base.Delete()
+	if len(fake.Live(ResourceProgram)) != 0 {
+		t.Errorf("programs left alive: %v", fake.Live(ResourceProgram))
+	}
+}
@@ -0,0 +1,402 @@
+package gogl
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+const (
+	defaultWatcherDebounce = 100 * time.Millisecond
+	watcherPollInterval    = 500 * time.Millisecond
+)
+
+// watches the files of shaders on a background goroutine so checking for
+// changes each frame doesn't stat anything. Changes are debounced and
+// queued until ProcessReloads is called on the thread with the gl context
+//
+// files on the os are watched with inotify on linux, other file systems
+// and other platforms fall back to polling the mod times
+type ShaderWatcher struct {
+	mu      sync.Mutex
+	shaders map[*ShaderWithPaths]*watchedShader
+	// the shaders using each file watched by the notifier by absolute path
+	byPath map[string][]watchedFile
+	// how many watched paths are in each directory given to the notifier
+	dirs    map[string]int
+	pending map[*ShaderWithPaths]map[string]bool
+
+	notifier fileNotifier
+	changes  chan watchedFile
+	debounce time.Duration
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+type watchedShader struct {
+	fsys fs.FS
+	// only set for shaders that are polled
+	modTimes map[string]time.Time
+	absPaths []string
+}
+
+type watchedFile struct {
+	shader *ShaderWithPaths
+	path   string
+}
+
+// a source of file change notifications for whole directories
+type fileNotifier interface {
+	watchDir(dir string) error
+	unwatchDir(dir string) error
+	// reads notifications until close is called
+	readEvents()
+	close() error
+}
+
+// a watcher that waits for debounce after the last change to a shader before
+// queueing its reload, a debounce of 0 uses the default of 100ms
+func NewShaderWatcher(debounce time.Duration) *ShaderWatcher {
+	if debounce <= 0 {
+		debounce = defaultWatcherDebounce
+	}
+
+	w := ShaderWatcher{
+		shaders:  make(map[*ShaderWithPaths]*watchedShader),
+		byPath:   make(map[string][]watchedFile),
+		dirs:     make(map[string]int),
+		pending:  make(map[*ShaderWithPaths]map[string]bool),
+		changes:  make(chan watchedFile, 64),
+		debounce: debounce,
+		done:     make(chan struct{}),
+	}
+
+	//without a notifier everything is polled
+	notifier, err := newFileNotifier(w.fileChanged)
+	if err == nil {
+		w.notifier = notifier
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			notifier.readEvents()
+		}()
+	}
+
+	w.wg.Add(2)
+	go w.debounceLoop()
+	go w.pollLoop()
+
+	return &w
+}
+
+// starts watching the files of s, CheckShadersForChanges on s then only
+// reloads it once the watcher has seen a change. Variants made with
+// WithDefines are watched by the same watcher
+// shaders that can't hot reload are ignored
+func (w *ShaderWatcher) Add(s *ShaderWithPaths) {
+	if !s.hotReload {
+		return
+	}
+	s.watcher = w
+	w.update(s)
+}
+
+// stops watching s, it goes back to checking its own files
+func (w *ShaderWatcher) Remove(s *ShaderWithPaths) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.forget(s)
+	delete(w.shaders, s)
+	delete(w.pending, s)
+	if s.watcher == w {
+		s.watcher = nil
+	}
+}
+
+// reloads every shader that has changed since the last call and
+// returns how many there were. It must be called on the gl thread
+func (w *ShaderWatcher) ProcessReloads() int {
+	w.mu.Lock()
+	if len(w.pending) == 0 {
+		w.mu.Unlock()
+		return 0
+	}
+	pending := w.pending
+	w.pending = make(map[*ShaderWithPaths]map[string]bool)
+	w.mu.Unlock()
+
+	for s, paths := range pending {
+		for path := range paths {
+			s.printModified(path)
+		}
+		s.reload()
+	}
+	return len(pending)
+}
+
+// reloads s if it has changed, used by CheckShadersForChanges
+func (w *ShaderWatcher) processShader(s *ShaderWithPaths) {
+	w.mu.Lock()
+	paths, ok := w.pending[s]
+	delete(w.pending, s)
+	w.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	for path := range paths {
+		s.printModified(path)
+	}
+	s.reload()
+}
+
+// stops the background goroutines, the shaders
+// go back to checking their own files. Closing it again does nothing
+func (w *ShaderWatcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() { err = w.close() })
+	return err
+}
+
+func (w *ShaderWatcher) close() error {
+	close(w.done)
+	var err error
+	if w.notifier != nil {
+		err = w.notifier.close()
+	}
+	w.wg.Wait()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for s := range w.shaders {
+		if s.watcher == w {
+			s.watcher = nil
+		}
+	}
+	w.shaders = make(map[*ShaderWithPaths]*watchedShader)
+	w.byPath = make(map[string][]watchedFile)
+	w.dirs = make(map[string]int)
+	w.pending = make(map[*ShaderWithPaths]map[string]bool)
+	return err
+}
+
+// rewatches the files of s, they change when a reload adds or removes includes
+func (w *ShaderWatcher) update(s *ShaderWithPaths) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.forget(s)
+	watched := watchedShader{fsys: s.fsys}
+	w.shaders[s] = &watched
+
+	if _, ok := s.fsys.(glsl.OSFS); ok && w.notifier != nil && w.watchPaths(s, &watched) {
+		return
+	}
+
+	//polled shaders start from the mod times of the build they came from
+	watched.modTimes = make(map[string]time.Time, len(s.modTimes))
+	for path, modTime := range s.modTimes {
+		watched.modTimes[path] = modTime
+	}
+}
+
+// adds the files of s to the notifier and reports whether all of them could be
+// watched, if not the ones that were added are forgotten so s can be polled
+func (w *ShaderWatcher) watchPaths(s *ShaderWithPaths, watched *watchedShader) bool {
+	for path := range s.modTimes {
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			w.forget(s)
+			return false
+		}
+
+		dir := filepath.Dir(absPath)
+		if w.dirs[dir] == 0 {
+			if err := w.notifier.watchDir(dir); err != nil {
+				w.forget(s)
+				return false
+			}
+		}
+		if len(w.byPath[absPath]) == 0 {
+			w.dirs[dir]++
+		}
+
+		w.byPath[absPath] = append(w.byPath[absPath], watchedFile{s, path})
+		watched.absPaths = append(watched.absPaths, absPath)
+	}
+	return true
+}
+
+// removes s from the notified paths and stops watching
+// directories that have nothing left in them, w.mu must be held
+func (w *ShaderWatcher) forget(s *ShaderWithPaths) {
+	watched, ok := w.shaders[s]
+	if !ok {
+		return
+	}
+	for _, absPath := range watched.absPaths {
+		files := w.byPath[absPath][:0]
+		for _, file := range w.byPath[absPath] {
+			if file.shader != s {
+				files = append(files, file)
+			}
+		}
+		if len(files) == 0 {
+			delete(w.byPath, absPath)
+			w.unwatchPath(absPath)
+		} else {
+			w.byPath[absPath] = files
+		}
+	}
+	watched.absPaths = nil
+}
+
+// a path of dir is no longer watched, w.mu must be held
+func (w *ShaderWatcher) unwatchPath(absPath string) {
+	dir := filepath.Dir(absPath)
+	w.dirs[dir]--
+	if w.dirs[dir] > 0 {
+		return
+	}
+	delete(w.dirs, dir)
+	//the watch is gone either way so there's nothing to do with the error
+	_ = w.notifier.unwatchDir(dir)
+}
+
+// called by the notifier from its own goroutine
+func (w *ShaderWatcher) fileChanged(absPath string) {
+	w.mu.Lock()
+	files := append([]watchedFile(nil), w.byPath[absPath]...)
+	w.mu.Unlock()
+
+	for _, file := range files {
+		w.send(file)
+	}
+}
+
+func (w *ShaderWatcher) send(file watchedFile) {
+	select {
+	case w.changes <- file:
+	case <-w.done:
+	}
+}
+
+// queues the shaders once their files have stopped changing
+// so saving several files at once only reloads them once
+func (w *ShaderWatcher) debounceLoop() {
+	defer w.wg.Done()
+
+	changed := make(map[*ShaderWithPaths]map[string]bool)
+	timer := time.NewTimer(w.debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case file := <-w.changes:
+			if changed[file.shader] == nil {
+				changed[file.shader] = make(map[string]bool)
+			}
+			changed[file.shader][file.path] = true
+
+			if !timer.Stop() {
+				select {
+				case <-timer.C:
+				default:
+				}
+			}
+			timer.Reset(w.debounce)
+
+		case <-timer.C:
+			w.mu.Lock()
+			for s, paths := range changed {
+				if _, ok := w.shaders[s]; !ok {
+					continue //removed while waiting
+				}
+				if w.pending[s] == nil {
+					w.pending[s] = make(map[string]bool)
+				}
+				for path := range paths {
+					w.pending[s][path] = true
+				}
+			}
+			w.mu.Unlock()
+			changed = make(map[*ShaderWithPaths]map[string]bool)
+
+		case <-w.done:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (w *ShaderWatcher) pollLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(watcherPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, file := range w.poll() {
+				w.send(file)
+			}
+		case <-w.done:
+			return
+		}
+	}
+}
+
+type polledFile struct {
+	watchedFile
+	watched *watchedShader
+	modTime time.Time
+}
+
+// the files of polled shaders that have changed since the last poll
+//
+// the files are stat'd without holding w.mu so a slow file system doesn't
+// block ProcessReloads, a shader updated in the meantime keeps its new mod times
+func (w *ShaderWatcher) poll() []watchedFile {
+	w.mu.Lock()
+	var files []polledFile
+	for s, watched := range w.shaders {
+		for path, modTime := range watched.modTimes {
+			files = append(files, polledFile{watchedFile{s, path}, watched, modTime})
+		}
+	}
+	w.mu.Unlock()
+
+	var changed []polledFile
+	for _, file := range files {
+		//a missing file has a zero mod time so creating
+		//or deleting a file also counts as a modification
+		newModTime, _ := getModTime(file.watched.fsys, file.path)
+		if newModTime.Equal(file.modTime) {
+			continue
+		}
+		file.modTime = newModTime
+		changed = append(changed, file)
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var modified []watchedFile
+	for _, file := range changed {
+		if w.shaders[file.shader] != file.watched {
+			continue //removed or rewatched while polling
+		}
+		file.watched.modTimes[file.path] = file.modTime
+		modified = append(modified, file.watchedFile)
+	}
+	return modified
+}
@@ -0,0 +1,124 @@
+package gogl
+
+import (
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/moltenwolfcub/gogl-utils/glsl"
+)
+
+func TestShaderWatcherPoll(t *testing.T) {
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"a.vert":    {Data: []byte("a"), ModTime: modified},
+		"a.frag":    {Data: []byte("b"), ModTime: modified},
+		"util.glsl": {Data: []byte("c"), ModTime: modified},
+	}
+	s := &ShaderWithPaths{
+		fsys: files,
+		modTimes: map[string]time.Time{
+			"a.vert":    modified,
+			"a.frag":    modified,
+			"util.glsl": modified,
+		},
+	}
+
+	//no goroutines are started so poll can be called directly
+	w := &ShaderWatcher{
+		shaders: make(map[*ShaderWithPaths]*watchedShader),
+		byPath:  make(map[string][]watchedFile),
+		dirs:    make(map[string]int),
+		pending: make(map[*ShaderWithPaths]map[string]bool),
+	}
+	w.update(s)
+
+	if changed := w.poll(); len(changed) != 0 {
+		t.Fatalf("nothing changed but poll returned %v", changed)
+	}
+
+	files["a.frag"].ModTime = modified.Add(time.Second)
+	changed := w.poll()
+	if len(changed) != 1 || changed[0] != (watchedFile{s, "a.frag"}) {
+		t.Fatalf("poll returned %v, want a.frag", changed)
+	}
+	if changed := w.poll(); len(changed) != 0 {
+		t.Errorf("the new mod time wasn't kept, poll returned %v", changed)
+	}
+
+	delete(files, "util.glsl")
+	changed = w.poll()
+	if len(changed) != 1 || changed[0].path != "util.glsl" {
+		t.Errorf("poll returned %v, want the deleted util.glsl", changed)
+	}
+
+	w.Remove(s)
+	files["a.vert"].ModTime = modified.Add(2 * time.Second)
+	if changed := w.poll(); len(changed) != 0 {
+		t.Errorf("a removed shader was polled: %v", changed)
+	}
+}
+
+type fakeNotifier struct {
+	watched map[string]bool
+}
+
+func (n *fakeNotifier) watchDir(dir string) error {
+	n.watched[dir] = true
+	return nil
+}
+
+func (n *fakeNotifier) unwatchDir(dir string) error {
+	delete(n.watched, dir)
+	return nil
+}
+
+func (n *fakeNotifier) readEvents()  {}
+func (n *fakeNotifier) close() error { return nil }
+
+func TestShaderWatcherUnwatchesDirs(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared", "util.glsl")
+	a := &ShaderWithPaths{
+		fsys:     glsl.OSFS{},
+		modTimes: map[string]time.Time{filepath.Join(dir, "a.vert"): {}, shared: {}},
+	}
+	b := &ShaderWithPaths{
+		fsys:     glsl.OSFS{},
+		modTimes: map[string]time.Time{filepath.Join(dir, "b.vert"): {}, shared: {}},
+	}
+
+	notifier := &fakeNotifier{watched: make(map[string]bool)}
+	w := &ShaderWatcher{
+		shaders:  make(map[*ShaderWithPaths]*watchedShader),
+		byPath:   make(map[string][]watchedFile),
+		dirs:     make(map[string]int),
+		pending:  make(map[*ShaderWithPaths]map[string]bool),
+		notifier: notifier,
+	}
+	w.update(a)
+	w.update(b)
+	if len(notifier.watched) != 2 {
+		t.Fatalf("watched %v, want both directories", notifier.watched)
+	}
+
+	w.Remove(a)
+	if len(notifier.watched) != 2 {
+		t.Errorf("directories b still uses were unwatched: %v", notifier.watched)
+	}
+	w.Remove(b)
+	if len(notifier.watched) != 0 {
+		t.Errorf("%v are still watched after every shader was removed", notifier.watched)
+	}
+}
+
+func TestShaderWatcherCloseTwice(t *testing.T) {
+	w := NewShaderWatcher(0)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Errorf("second close returned %v", err)
+	}
+}
@@ -1,255 +1,740 @@
 package gogl
 
 import (
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
 	"time"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/moltenwolfcub/gogl-utils/glsl"
 )
 
 type ProgramID uint32
 type ShaderID uint32
 
+// returned when a shader fails to compile
+// Path is empty for shaders that weren't loaded from a file
+type ShaderCompileError struct {
+	Path  string
+	Stage uint32
+	Log   string
+}
+
+func (e *ShaderCompileError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("Failed to compile %s shader:\n%s", StageName(e.Stage), e.Log)
+	}
+	return fmt.Sprintf("Failed to compile %s shader %s:\n%s", StageName(e.Stage), e.Path, e.Log)
+}
+
+// returned when the shaders compiled but the program failed to link
+type ProgramLinkError struct {
+	Paths []string
+	Log   string
+}
+
+func (e *ProgramLinkError) Error() string {
+	if len(e.Paths) == 0 {
+		return "Failed to link program:\n" + e.Log
+	}
+	return fmt.Sprintf("Failed to link program (%s):\n%s", strings.Join(e.Paths, ", "), e.Log)
+}
+
+// the human readable name of a shader stage e.g. "vertex"
+func StageName(stage uint32) string {
+	switch stage {
+	case gl.VERTEX_SHADER:
+		return "vertex"
+	case gl.FRAGMENT_SHADER:
+		return "fragment"
+	case gl.GEOMETRY_SHADER:
+		return "geometry"
+	default:
+		return fmt.Sprintf("unknown (0x%X)", stage)
+	}
+}
+
+// a shader stage loaded from a file
+type StageFile struct {
+	Stage uint32 // e.g. gl.GEOMETRY_SHADER
+	Path  string
+}
+
+// a shader stage compiled from source code, Stage is e.g. gl.GEOMETRY_SHADER
+// Source can be a preprocessed shader or just code e.g. &ShaderSource{Source: code}
+type StageSource = glsl.StageSource
+
+// same as CreateProgram but returns the error rather than panicking
+func CreateProgramErr(vertPath string, fragPath string) (ProgramID, error) {
+	return CreateProgramFromStageFiles(
+		StageFile{gl.VERTEX_SHADER, vertPath},
+		StageFile{gl.FRAGMENT_SHADER, fragPath},
+	)
+}
+
+// builds a program from any of the vertex, geometry and fragment stages
+func CreateProgramFromStageFiles(stages ...StageFile) (ProgramID, error) {
+	id, _, err := createProgram(glsl.OSFS{}, stages, nil)
+	return id, err
+}
+
+// same as CreateProgramFromStageFiles but reads the files from fsys
+func CreateProgramFromStageFilesFS(fsys fs.FS, stages ...StageFile) (ProgramID, error) {
+	id, _, err := createProgram(fsys, stages, nil)
+	return id, err
+}
+
+func MustCreateProgramFromStageFiles(stages ...StageFile) ProgramID {
+	id, err := CreateProgramFromStageFiles(stages...)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// also returns every file the program was built from including
+// the #include'd ones, even when building the program failed
+func createProgram(fsys fs.FS, stages []StageFile, defines Defines) (ProgramID, []string, error) {
+	stageTypes := make([]uint32, len(stages))
+	paths := make([]string, len(stages))
+	for i, stage := range stages {
+		stageTypes[i] = stage.Stage
+		paths[i] = stage.Path
+	}
+	if err := validateStages(stageTypes); err != nil {
+		return 0, paths, err
+	}
+
+	var files []string
+	preprocessed := make([]*ShaderSource, len(stages))
+	sources := make([]StageSource, len(stages))
+	for i, stage := range stages {
+		source, err := PreprocessShaderFS(fsys, stage.Path)
+		files = append(files, source.Files...)
+		if err != nil {
+			return 0, files, err
+		}
+		preprocessed[i] = source
+		injected, err := InjectDefines(source.Source, defines)
+		if err != nil {
+			return 0, files, err
+		}
+		sources[i] = StageSource{
+			Stage:  glsl.Stage(stage.Stage),
+			Source: &ShaderSource{Source: injected, Files: source.Files},
+		}
+	}
+
+	id, err := withProgramCache(sources, func() (ProgramID, error) {
+		shaders := make([]ShaderID, 0, len(stages))
+		for i, stage := range stages {
+			shader, err := compileShaderSource(stage.Path, preprocessed[i], stage.Stage, defines)
+			if err != nil {
+				deleteShaders(shaders)
+				return 0, err
+			}
+			shaders = append(shaders, shader)
+		}
+		return linkProgram(paths, shaders...)
+	})
+	return id, files, err
+}
+
+// checks that every stage exists in gl 3.3 and is only used once
+func validateStages(stages []uint32) error {
+	if len(stages) == 0 {
+		return errors.New("a program needs at least one shader stage")
+	}
+
+	seen := make(map[uint32]bool)
+	for _, stage := range stages {
+		switch stage {
+		case gl.VERTEX_SHADER, gl.GEOMETRY_SHADER, gl.FRAGMENT_SHADER:
+		default:
+			return fmt.Errorf("unsupported shader stage %s", StageName(stage))
+		}
+		if seen[stage] {
+			return fmt.Errorf("the %s stage is used more than once", StageName(stage))
+		}
+		seen[stage] = true
+	}
+	return nil
+}
+
+func deleteShaders(shaders []ShaderID) {
+	for _, shader := range shaders {
+		shader.Delete()
+	}
+}
+
+func (id ProgramID) Delete() {
+	if id == 0 {
+		return
+	}
+	ogl.DeleteProgram(uint32(id))
+	untrackResource(ResourceProgram, uint32(id))
+}
+
+func (id ShaderID) Delete() {
+	if id == 0 {
+		return
+	}
+	ogl.DeleteShader(uint32(id))
+	untrackResource(ResourceShader, uint32(id))
+}
+
 func CreateProgram(vertPath string, fragPath string) ProgramID {
-	vert := LoadShader(vertPath, gl.VERTEX_SHADER)
-	frag := LoadShader(fragPath, gl.FRAGMENT_SHADER)
+	id, err := CreateProgramErr(vertPath, fragPath)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
 
-	shaderProgram := gl.CreateProgram()
-	gl.AttachShader(shaderProgram, uint32(vert))
-	gl.AttachShader(shaderProgram, uint32(frag))
-	gl.LinkProgram(shaderProgram)
-	var success int32
-	gl.GetProgramiv(shaderProgram, gl.LINK_STATUS, &success)
-	if success == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shaderProgram, gl.INFO_LOG_LENGTH, &logLength)
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(shaderProgram, logLength, nil, gl.Str(log))
-		panic("Failed to link program:\n" + log)
+// same as LoadShader but returns the error rather than panicking
+func LoadShaderErr(path string, shaderType uint32) (ShaderID, error) {
+	id, _, err := loadShader(glsl.OSFS{}, path, shaderType, nil)
+	return id, err
+}
+
+// same as LoadShaderErr but reads the shader and its includes from fsys
+func LoadShaderFS(fsys fs.FS, path string, shaderType uint32) (ShaderID, error) {
+	id, _, err := loadShader(fsys, path, shaderType, nil)
+	return id, err
+}
+
+func loadShader(fsys fs.FS, path string, shaderType uint32, defines Defines) (ShaderID, []string, error) {
+	source, err := PreprocessShaderFS(fsys, path)
+	if err != nil {
+		return 0, source.Files, err
 	}
-	gl.DeleteShader(uint32(vert))
-	gl.DeleteShader(uint32(frag))
 
-	return ProgramID(shaderProgram)
+	shaderId, err := compileShaderSource(path, source, shaderType, defines)
+	return shaderId, source.Files, err
+}
+
+// compiles a preprocessed shader with compile errors pointing at the original files
+func compileShaderSource(path string, source *ShaderSource, shaderType uint32, defines Defines) (ShaderID, error) {
+	injected, err := InjectDefines(source.Source, defines)
+	if err != nil {
+		return 0, err
+	}
+	shaderId, err := CreateShaderErr(injected, shaderType)
+	if compileErr, ok := err.(*ShaderCompileError); ok {
+		compileErr.Path = path
+		compileErr.Log = source.RemapLog(compileErr.Log)
+	}
+	return shaderId, err
 }
 
+// loads a shader from a file, expanding any #include directives
 func LoadShader(path string, shaderType uint32) ShaderID {
-	shaderFile, err := os.ReadFile(path)
+	id, err := LoadShaderErr(path, shaderType)
 	if err != nil {
 		panic(err)
 	}
+	return id
+}
 
-	shaderId := CreateShader(string(shaderFile), shaderType)
-	return shaderId
+func MustLoadShaderFS(fsys fs.FS, path string, shaderType uint32) ShaderID {
+	id, err := LoadShaderFS(fsys, path, shaderType)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// same as CreateProgramFromShaders but returns the error rather than panicking
+func CreateProgramFromShadersErr(vertShader string, fragShader string) (ProgramID, error) {
+	return CreateProgramFromStageSources(
+		StageSource{Stage: gl.VERTEX_SHADER, Source: &ShaderSource{Source: vertShader}},
+		StageSource{Stage: gl.FRAGMENT_SHADER, Source: &ShaderSource{Source: fragShader}},
+	)
+}
+
+// builds a program from source code for any of the vertex, geometry and fragment stages
+func CreateProgramFromStageSources(stages ...StageSource) (ProgramID, error) {
+	return createProgramFromSources(stages, nil)
+}
+
+func createProgramFromSources(stages []StageSource, defines Defines) (ProgramID, error) {
+	stageTypes := make([]uint32, len(stages))
+	for i, stage := range stages {
+		stageTypes[i] = uint32(stage.Stage)
+	}
+	if err := validateStages(stageTypes); err != nil {
+		return 0, err
+	}
+
+	sources := make([]StageSource, len(stages))
+	for i, stage := range stages {
+		injected, err := InjectDefines(stage.Source.Source, defines)
+		if err != nil {
+			return 0, err
+		}
+		sources[i] = StageSource{
+			Stage:  stage.Stage,
+			Source: &ShaderSource{Source: injected, Files: stage.Source.Files},
+		}
+	}
+
+	return withProgramCache(sources, func() (ProgramID, error) {
+		shaders := make([]ShaderID, 0, len(stages))
+		for _, stage := range stages {
+			//compile errors are remapped through the #line directives of preprocessed sources
+			shader, err := compileShaderSource("", stage.Source, uint32(stage.Stage), defines)
+			if err != nil {
+				deleteShaders(shaders)
+				return 0, err
+			}
+			shaders = append(shaders, shader)
+		}
+		return linkProgram(nil, shaders...)
+	})
+}
+
+func MustCreateProgramFromStageSources(stages ...StageSource) ProgramID {
+	id, err := CreateProgramFromStageSources(stages...)
+	if err != nil {
+		panic(err)
+	}
+	return id
 }
 
 func CreateProgramFromShaders(vertShader string, fragShader string) ProgramID {
-	vert := CreateShader(vertShader, gl.VERTEX_SHADER)
-	frag := CreateShader(fragShader, gl.FRAGMENT_SHADER)
+	id, err := CreateProgramFromShadersErr(vertShader, fragShader)
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// links the shaders into a new program
+// the shaders are always deleted, even if linking fails
+func linkProgram(paths []string, shaders ...ShaderID) (ProgramID, error) {
+	shaderProgram := ogl.CreateProgram()
+	trackResource(ResourceProgram, shaderProgram)
+	for _, shader := range shaders {
+		ogl.AttachShader(shaderProgram, uint32(shader))
+	}
+	if programCache != nil && programCache.supported {
+		ogl.ProgramParameteri(shaderProgram, gl.PROGRAM_BINARY_RETRIEVABLE_HINT, gl.TRUE)
+	}
+	ogl.LinkProgram(shaderProgram)
+	deleteShaders(shaders)
 
-	shaderProgram := gl.CreateProgram()
-	gl.AttachShader(shaderProgram, uint32(vert))
-	gl.AttachShader(shaderProgram, uint32(frag))
-	gl.LinkProgram(shaderProgram)
 	var success int32
-	gl.GetProgramiv(shaderProgram, gl.LINK_STATUS, &success)
+	ogl.GetProgramiv(shaderProgram, gl.LINK_STATUS, &success)
 	if success == gl.FALSE {
 		var logLength int32
-		gl.GetShaderiv(shaderProgram, gl.INFO_LOG_LENGTH, &logLength)
+		ogl.GetProgramiv(shaderProgram, gl.INFO_LOG_LENGTH, &logLength)
 		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(shaderProgram, logLength, nil, gl.Str(log))
-		panic("Failed to link program:\n" + log)
+		ogl.GetProgramInfoLog(shaderProgram, logLength, nil, gl.Str(log))
+		ProgramID(shaderProgram).Delete()
+
+		return 0, &ProgramLinkError{
+			Paths: paths,
+			Log:   strings.TrimRight(log, "\x00"),
+		}
 	}
-	gl.DeleteShader(uint32(vert))
-	gl.DeleteShader(uint32(frag))
 
-	return ProgramID(shaderProgram)
+	return ProgramID(shaderProgram), nil
 }
 
-func CreateShader(shaderSource string, shaderType uint32) ShaderID {
-	shaderId := gl.CreateShader(shaderType)
+// same as CreateShader but returns the error rather than panicking
+func CreateShaderErr(shaderSource string, shaderType uint32) (ShaderID, error) {
+	shaderId := ogl.CreateShader(shaderType)
+	trackResource(ResourceShader, shaderId)
 	shaderSource += "\x00"
 	csource, free := gl.Strs(shaderSource)
-	gl.ShaderSource(shaderId, 1, csource, nil)
+	ogl.ShaderSource(shaderId, 1, csource, nil)
 	free()
-	gl.CompileShader(shaderId)
+	ogl.CompileShader(shaderId)
 	var status int32
-	gl.GetShaderiv(shaderId, gl.COMPILE_STATUS, &status)
+	ogl.GetShaderiv(shaderId, gl.COMPILE_STATUS, &status)
 	if status == gl.FALSE {
 		var logLength int32
-		gl.GetShaderiv(shaderId, gl.INFO_LOG_LENGTH, &logLength)
+		ogl.GetShaderiv(shaderId, gl.INFO_LOG_LENGTH, &logLength)
 		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shaderId, logLength, nil, gl.Str(log))
-		panic("Failed to compile shader:\n" + log)
+		ogl.GetShaderInfoLog(shaderId, logLength, nil, gl.Str(log))
+		ShaderID(shaderId).Delete()
+
+		return 0, &ShaderCompileError{
+			Stage: shaderType,
+			Log:   strings.TrimRight(log, "\x00"),
+		}
 	}
-	return ShaderID(shaderId)
+	return ShaderID(shaderId), nil
+}
+
+func CreateShader(shaderSource string, shaderType uint32) ShaderID {
+	id, err := CreateShaderErr(shaderSource, shaderType)
+	if err != nil {
+		panic(err)
+	}
+	return id
 }
 
 type Shader interface {
 	CheckShadersForChanges()
 	Use()
 
+	// the error from the latest reload or nil if it succeeded
+	LastError() error
+	// callback is run after every reload with the reload's error
+	SetReloadCallback(callback func(err error))
+
+	// the active uniforms, refreshed whenever the shader reloads
+	Uniforms() []UniformInfo
+	// the active vertex attributes, refreshed whenever the shader reloads
+	Attributes() []AttributeInfo
+	// the location of an active vertex attribute or -1
+	AttributeLocation(name string) int32
+
+	// callback is run the first time each uniform is set with the wrong type
+	SetUniformErrorCallback(callback func(err error))
+
 	SetBool(name string, value bool)
 	SetInt(name string, value int32)
+	SetUint(name string, value uint32)
 	SetFloat(name string, value float32)
+	SetSampler(name string, unit int32)
+	SetVec2(name string, value mgl32.Vec2)
 	SetVec3(name string, value mgl32.Vec3)
+	SetVec4(name string, value mgl32.Vec4)
+	SetIVec2(name string, value [2]int32)
+	SetIVec3(name string, value [3]int32)
+	SetIVec4(name string, value [4]int32)
+	SetUVec2(name string, value [2]uint32)
+	SetUVec3(name string, value [3]uint32)
+	SetUVec4(name string, value [4]uint32)
+	SetMatrix2(name string, value mgl32.Mat2)
+	SetMatrix3(name string, value mgl32.Mat3)
 	SetMatrix4(name string, value mgl32.Mat4)
+
+	SetBoolArray(name string, values []bool)
+	SetIntArray(name string, values []int32)
+	SetUintArray(name string, values []uint32)
+	SetFloatArray(name string, values []float32)
+	SetSamplerArray(name string, units []int32)
+	SetVec2Array(name string, values []mgl32.Vec2)
+	SetVec3Array(name string, values []mgl32.Vec3)
+	SetVec4Array(name string, values []mgl32.Vec4)
+	SetIVec2Array(name string, values [][2]int32)
+	SetIVec3Array(name string, values [][3]int32)
+	SetIVec4Array(name string, values [][4]int32)
+	SetUVec2Array(name string, values [][2]uint32)
+	SetUVec3Array(name string, values [][3]uint32)
+	SetUVec4Array(name string, values [][4]uint32)
+	SetMatrix2Array(name string, values []mgl32.Mat2)
+	SetMatrix3Array(name string, values []mgl32.Mat3)
+	SetMatrix4Array(name string, values []mgl32.Mat4)
+
+	// sets every field of a struct as the uniform prefix.field
+	SetStruct(prefix string, value any) error
+
+	// links a uniform block to a uniform buffer binding point
+	BindUniformBlock(blockName string, binding uint32) error
+
+	// frees the program, the shader can't be used after this
+	Delete()
 }
 
 type ShaderWithPaths struct {
-	id          ProgramID
-	vertPath    string
-	vertModTime time.Time
-	fragPath    string
-	fragModTime time.Time
+	program
+	fsys      fs.FS
+	stages    []StageFile
+	defines   Defines
+	hotReload bool
+	// every file the program is built from, including #include'd ones
+	modTimes map[string]time.Time
+	// set once the shader has been added to a ShaderWatcher
+	watcher *ShaderWatcher
+
+	lastErr  error
+	onReload func(err error)
+}
+
+// same as NewShaderFromFilePaths but returns the error rather than panicking
+func NewShaderFromFilePathsErr(vertPath string, fragPath string) (*ShaderWithPaths, error) {
+	return NewShaderFromStageFiles(
+		StageFile{gl.VERTEX_SHADER, vertPath},
+		StageFile{gl.FRAGMENT_SHADER, fragPath},
+	)
 }
 
 func NewShaderFromFilePaths(vertPath string, fragPath string) *ShaderWithPaths {
-	id := CreateProgram(vertPath, fragPath)
+	s, err := NewShaderFromFilePathsErr(vertPath, fragPath)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// a hot reloading shader made of any of the vertex, geometry and fragment stages
+func NewShaderFromStageFiles(stages ...StageFile) (*ShaderWithPaths, error) {
+	return NewShaderFromStageFilesFS(glsl.OSFS{}, stages...)
+}
+
+// a shader loaded from fsys, it only hot reloads if fsys has
+// mod times e.g. os.DirFS in development but not embed.FS in release
+func NewShaderFromFS(fsys fs.FS, vertPath string, fragPath string) (*ShaderWithPaths, error) {
+	return NewShaderFromStageFilesFS(fsys,
+		StageFile{gl.VERTEX_SHADER, vertPath},
+		StageFile{gl.FRAGMENT_SHADER, fragPath},
+	)
+}
+
+func MustNewShaderFromFS(fsys fs.FS, vertPath string, fragPath string) *ShaderWithPaths {
+	s, err := NewShaderFromFS(fsys, vertPath, fragPath)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// same as NewShaderFromStageFiles but reads the files from fsys
+func NewShaderFromStageFilesFS(fsys fs.FS, stages ...StageFile) (*ShaderWithPaths, error) {
+	cleaned := make([]StageFile, len(stages))
+	for i, stage := range stages {
+		cleaned[i] = StageFile{stage.Stage, glsl.CleanPath(fsys, stage.Path)}
+	}
+
+	return newShaderWithPaths(fsys, cleaned, nil)
+}
+
+func newShaderWithPaths(fsys fs.FS, stages []StageFile, defines Defines) (*ShaderWithPaths, error) {
+	id, files, err := createProgram(fsys, stages, defines)
+	if err != nil {
+		return nil, err
+	}
 
 	s := ShaderWithPaths{
-		id:       id,
-		vertPath: vertPath,
-		fragPath: fragPath,
+		program:   newProgram(id),
+		fsys:      fsys,
+		stages:    stages,
+		defines:   defines,
+		hotReload: canHotReload(fsys, files...),
+	}
+	s.watchFiles(files)
+
+	return &s, nil
+}
+
+// builds a new shader from the same files with defines added to this shader's
+// defines. Use ShaderVariants to cache the variants of a shader
+func (s *ShaderWithPaths) WithDefines(defines Defines) (*ShaderWithPaths, error) {
+	if err := defines.validate(); err != nil {
+		return nil, err
+	}
+	variant, err := newShaderWithPaths(s.fsys, s.stages, s.defines.merge(defines))
+	if err != nil {
+		return nil, err
+	}
+	if s.watcher != nil {
+		s.watcher.Add(variant)
+	}
+	return variant, nil
+}
 
-		vertModTime: getFileModTime(vertPath),
-		fragModTime: getFileModTime(fragPath),
+func (s *ShaderWithPaths) Defines() Defines {
+	return s.defines.merge(nil)
+}
+
+// frees the program and stops its ShaderWatcher watching it
+func (s *ShaderWithPaths) Delete() {
+	if s.watcher != nil {
+		s.watcher.Remove(s)
 	}
+	s.hotReload = false
+	s.program.Delete()
+}
 
-	return &s
+func MustNewShaderFromStageFilesFS(fsys fs.FS, stages ...StageFile) *ShaderWithPaths {
+	s, err := NewShaderFromStageFilesFS(fsys, stages...)
+	if err != nil {
+		panic(err)
+	}
+	return s
 }
 
-func (s *ShaderWithPaths) Use() {
-	UseProgram(s.id)
+func MustNewShaderFromStageFiles(stages ...StageFile) *ShaderWithPaths {
+	s, err := NewShaderFromStageFiles(stages...)
+	if err != nil {
+		panic(err)
+	}
+	return s
 }
 
 func (s *ShaderWithPaths) CheckShadersForChanges() {
-	vertModTime := getFileModTime(s.vertPath)
-	fragModTime := getFileModTime(s.fragPath)
-	if v, f := !vertModTime.Equal(s.vertModTime), !fragModTime.Equal(s.fragModTime); v || f {
-		if v {
-			fmt.Printf("A vertex shader file has been modified: %s\n", s.vertPath)
-			s.vertModTime = vertModTime
-		}
-		if f {
-			fmt.Printf("A fragment shader file has been modified: %s\n", s.fragPath)
-			s.fragModTime = fragModTime
+	if !s.hotReload {
+		return
+	}
+	if s.watcher != nil {
+		s.watcher.processShader(s)
+		return
+	}
+
+	modified := false
+	for path, modTime := range s.modTimes {
+		//a missing file has a zero mod time so creating
+		//or deleting a file also counts as a modification
+		newModTime, _ := getModTime(s.fsys, path)
+		if newModTime.Equal(modTime) {
+			continue
 		}
-		id := CreateProgram(s.vertPath, s.fragPath)
 
-		gl.DeleteProgram(uint32(s.id))
-		s.id = id
+		s.printModified(path)
+		modified = true
+	}
+
+	if modified {
+		s.reload()
 	}
 }
 
-func (s *ShaderWithPaths) SetBool(name string, value bool) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
+func (s *ShaderWithPaths) printModified(path string) {
+	for _, stage := range s.stages {
+		if stage.Path == path {
+			fmt.Printf("A %s shader file has been modified: %s\n", StageName(stage.Stage), path)
+			return
+		}
+	}
+	fmt.Printf("An included shader file has been modified: %s\n", path)
+}
 
-	if value {
-		gl.Uniform1i(loc, 1)
+// the new program is built separately so the
+// current one keeps being used if it fails
+func (s *ShaderWithPaths) reload() {
+	id, files, err := createProgram(s.fsys, s.stages, s.defines)
+	if err == nil {
+		s.id.Delete()
+		s.setID(id)
 	} else {
-		gl.Uniform1i(loc, 0)
+		//a failed build may have stopped before reaching some includes
+		for path := range s.modTimes {
+			files = append(files, path)
+		}
+	}
+
+	s.watchFiles(files)
+	if s.watcher != nil {
+		s.watcher.update(s)
+	}
+
+	s.lastErr = err
+	if err != nil {
+		fmt.Printf("Failed to reload shader, keeping the previous program:\n%v\n", err)
+	}
+
+	if s.onReload != nil {
+		s.onReload(err)
 	}
 }
-func (s *ShaderWithPaths) SetInt(name string, value int32) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	gl.Uniform1i(loc, value)
+// records the current mod time of every file so
+// later changes to any of them trigger a reload
+func (s *ShaderWithPaths) watchFiles(files []string) {
+	s.modTimes = make(map[string]time.Time)
+	for _, stage := range s.stages {
+		files = append(files, stage.Path)
+	}
+	for _, path := range files {
+		path = glsl.CleanPath(s.fsys, path)
+		if _, ok := s.modTimes[path]; ok {
+			continue
+		}
+
+		modTime, _ := getModTime(s.fsys, path)
+		s.modTimes[path] = modTime
+	}
 }
-func (s *ShaderWithPaths) SetFloat(name string, value float32) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	gl.Uniform1f(loc, value)
+// whether CheckShadersForChanges looks for modified files
+func (s *ShaderWithPaths) HotReloadEnabled() bool {
+	return s.hotReload
 }
-func (s *ShaderWithPaths) SetMatrix4(name string, value mgl32.Mat4) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	m4 := [16]float32(value)
-	gl.UniformMatrix4fv(loc, 1, false, &m4[0])
+func (s *ShaderWithPaths) LastError() error {
+	return s.lastErr
 }
-func (s *ShaderWithPaths) SetVec3(name string, value mgl32.Vec3) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	v3 := [3]float32(value)
-	gl.Uniform3fv(loc, 1, &v3[0])
+func (s *ShaderWithPaths) SetReloadCallback(callback func(err error)) {
+	s.onReload = callback
 }
 
 type EmbeddedShader struct {
-	id         ProgramID
-	vertShader string
-	fragShader string
+	program
+	stages  []StageSource
+	defines Defines
 }
 
-func NewEmbeddedShader(vertShader string, fragShader string) *EmbeddedShader {
-	id := CreateProgramFromShaders(vertShader, fragShader)
-
-	s := EmbeddedShader{
-		id:         id,
-		vertShader: vertShader,
-		fragShader: fragShader,
-	}
-
-	return &s
+// same as NewEmbeddedShader but returns the error rather than panicking
+func NewEmbeddedShaderErr(vertShader string, fragShader string) (*EmbeddedShader, error) {
+	return NewEmbeddedShaderFromStages(
+		StageSource{Stage: gl.VERTEX_SHADER, Source: &ShaderSource{Source: vertShader}},
+		StageSource{Stage: gl.FRAGMENT_SHADER, Source: &ShaderSource{Source: fragShader}},
+	)
 }
 
-func (s *EmbeddedShader) Use() {
-	UseProgram(s.id)
+// an embedded shader made of any of the vertex, geometry and fragment stages
+func NewEmbeddedShaderFromStages(stages ...StageSource) (*EmbeddedShader, error) {
+	return newEmbeddedShader(stages, nil)
 }
-func (s *EmbeddedShader) CheckShadersForChanges() {}
 
-func (s *EmbeddedShader) SetBool(name string, value bool) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
+func newEmbeddedShader(stages []StageSource, defines Defines) (*EmbeddedShader, error) {
+	id, err := createProgramFromSources(stages, defines)
+	if err != nil {
+		return nil, err
+	}
 
-	if value {
-		gl.Uniform1i(loc, 1)
-	} else {
-		gl.Uniform1i(loc, 0)
+	s := EmbeddedShader{
+		program: newProgram(id),
+		stages:  stages,
+		defines: defines,
 	}
-}
-func (s *EmbeddedShader) SetInt(name string, value int32) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	gl.Uniform1i(loc, value)
+	return &s, nil
 }
-func (s *EmbeddedShader) SetFloat(name string, value float32) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	gl.Uniform1f(loc, value)
+// builds a new shader from the same sources with defines added to this shader's
+// defines. Use ShaderVariants to cache the variants of a shader
+func (s *EmbeddedShader) WithDefines(defines Defines) (*EmbeddedShader, error) {
+	if err := defines.validate(); err != nil {
+		return nil, err
+	}
+	return newEmbeddedShader(s.stages, s.defines.merge(defines))
 }
-func (s *EmbeddedShader) SetMatrix4(name string, value mgl32.Mat4) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	m4 := [16]float32(value)
-	gl.UniformMatrix4fv(loc, 1, false, &m4[0])
+func (s *EmbeddedShader) Defines() Defines {
+	return s.defines.merge(nil)
 }
-func (s *EmbeddedShader) SetVec3(name string, value mgl32.Vec3) {
-	name_cstr := gl.Str(name + "\x00")
-	loc := gl.GetUniformLocation(uint32(s.id), name_cstr)
 
-	v3 := [3]float32(value)
-	gl.Uniform3fv(loc, 1, &v3[0])
+func MustNewEmbeddedShaderFromStages(stages ...StageSource) *EmbeddedShader {
+	s, err := NewEmbeddedShaderFromStages(stages...)
+	if err != nil {
+		panic(err)
+	}
+	return s
 }
 
-func getFileModTime(path string) time.Time {
-	file, err := os.Stat(path)
+func NewEmbeddedShader(vertShader string, fragShader string) *EmbeddedShader {
+	s, err := NewEmbeddedShaderErr(vertShader, fragShader)
 	if err != nil {
 		panic(err)
 	}
-	return file.ModTime()
+	return s
 }
 
+func (s *EmbeddedShader) CheckShadersForChanges() {}
+
+// embedded shaders never reload so there is never an error
+func (s *EmbeddedShader) LastError() error                           { return nil }
+func (s *EmbeddedShader) SetReloadCallback(callback func(err error)) {}
+
 func UseProgram(id ProgramID) {
-	gl.UseProgram(uint32(id))
+	ogl.UseProgram(uint32(id))
 }
@@ -0,0 +1,325 @@
+package gogl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestShaderReload(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+	fake.CompileError = func(shaderType uint32, source string) string {
+		if strings.Contains(source, "broken") {
+			return "0:3(1): error: syntax error"
+		}
+		return ""
+	}
+
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"shader.vert": {Data: []byte(testVertexShader), ModTime: modified},
+		"shader.frag": {Data: []byte(testFragmentShader), ModTime: modified},
+	}
+	shader, err := NewShaderFromFS(files, "shader.vert", "shader.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+	if !shader.HotReloadEnabled() {
+		t.Fatal("hot reloading is disabled for files with mod times")
+	}
+
+	var reloads []error
+	shader.SetReloadCallback(func(err error) {
+		reloads = append(reloads, err)
+	})
+
+	shader.CheckShadersForChanges()
+	if len(reloads) != 0 {
+		t.Fatalf("reloaded with no changes: %v", reloads)
+	}
+
+	first := shader.id
+	files["shader.frag"] = &fstest.MapFile{
+		Data:    []byte("#version 330 core\nuniform vec3 tint;\nout vec4 color;\nvoid main() { color = vec4(tint, 1); }"),
+		ModTime: modified.Add(time.Second),
+	}
+	shader.CheckShadersForChanges()
+
+	if len(reloads) != 1 || reloads[0] != nil || shader.LastError() != nil {
+		t.Fatalf("reload errors %v, last error %v", reloads, shader.LastError())
+	}
+	if shader.id == first || fake.Program(first) != nil {
+		t.Errorf("program %d wasn't replaced by a new one, now %d", first, shader.id)
+	}
+	if _, ok := shader.uniformInfo("tint"); !ok {
+		t.Errorf("the new uniform wasn't reflected: %v", shader.Uniforms())
+	}
+
+	second := shader.id
+	files["shader.frag"] = &fstest.MapFile{
+		Data:    []byte("#version 330 core\nout vec4 color;\nbroken"),
+		ModTime: modified.Add(2 * time.Second),
+	}
+	shader.CheckShadersForChanges()
+
+	var compileErr *ShaderCompileError
+	if !errors.As(shader.LastError(), &compileErr) || len(reloads) != 2 || reloads[1] != shader.LastError() {
+		t.Errorf("got last error %v and reload errors %v, want a compile error", shader.LastError(), reloads)
+	}
+	if shader.id != second || fake.Program(second) == nil || !fake.Program(second).Linked {
+		t.Errorf("a failed reload replaced program %d with %d", second, shader.id)
+	}
+}
+
+func TestShaderReloadRecovers(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"shader.vert": {Data: []byte(testVertexShader), ModTime: modified},
+		"shader.frag": {Data: []byte(testFragmentShader), ModTime: modified},
+	}
+	shader, err := NewShaderFromFS(files, "shader.vert", "shader.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+	first := shader.id
+
+	//the include doesn't exist yet so the reload fails
+	files["shader.frag"] = &fstest.MapFile{
+		Data:    []byte("#version 330 core\n#include \"tint.glsl\"\nout vec4 color;\nvoid main() { color = vec4(tint, 1); }"),
+		ModTime: modified.Add(time.Second),
+	}
+	shader.CheckShadersForChanges()
+	if shader.LastError() == nil {
+		t.Fatal("a missing include didn't fail the reload")
+	}
+	if shader.id != first || !fake.Program(first).Linked {
+		t.Fatalf("the failed reload replaced program %d with %d", first, shader.id)
+	}
+
+	shader.Use()
+	if fake.CurrentProgram() != first {
+		t.Errorf("Use bound program %d, want the previous program %d", fake.CurrentProgram(), first)
+	}
+
+	//creating the missing file is a change like editing one
+	files["tint.glsl"] = &fstest.MapFile{Data: []byte("uniform vec3 tint;\n"), ModTime: modified.Add(2 * time.Second)}
+	shader.CheckShadersForChanges()
+	if shader.LastError() != nil {
+		t.Fatalf("the fixed shader didn't reload: %v", shader.LastError())
+	}
+	if shader.id == first || fake.Program(first) != nil {
+		t.Errorf("program %d wasn't replaced once the include existed", first)
+	}
+	if _, ok := shader.uniformInfo("tint"); !ok {
+		t.Errorf("the included uniform wasn't reflected: %v", shader.Uniforms())
+	}
+}
+
+func TestShaderReloadsIncludes(t *testing.T) {
+	_, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	modified := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	files := fstest.MapFS{
+		"shader.vert":        {Data: []byte(testVertexShader), ModTime: modified},
+		"shader.frag":        {Data: []byte("#version 330 core\n#include \"lib/tint.glsl\"\nout vec4 color;\nvoid main() { color = vec4(tint, 1); }"), ModTime: modified},
+		"lib/tint.glsl":      {Data: []byte("#include \"constants.glsl\"\nuniform vec3 tint;\n"), ModTime: modified},
+		"lib/constants.glsl": {Data: []byte("const float PI = 3.14159;\n"), ModTime: modified},
+	}
+	shader, err := NewShaderFromFS(files, "shader.vert", "shader.frag")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+
+	reloads := 0
+	shader.SetReloadCallback(func(err error) {
+		if err != nil {
+			t.Errorf("reload failed: %v", err)
+		}
+		reloads++
+	})
+
+	//includes of includes are relative to the file including them
+	files["lib/constants.glsl"].ModTime = modified.Add(time.Second)
+	shader.CheckShadersForChanges()
+	if reloads != 1 {
+		t.Fatalf("editing a nested include reloaded %d times", reloads)
+	}
+
+	//once nothing includes a file it isn't watched any more
+	files["shader.frag"] = &fstest.MapFile{Data: []byte(testFragmentShader), ModTime: modified.Add(2 * time.Second)}
+	shader.CheckShadersForChanges()
+	files["lib/tint.glsl"].ModTime = modified.Add(3 * time.Second)
+	shader.CheckShadersForChanges()
+	if reloads != 2 {
+		t.Errorf("got %d reloads, want one for the edited fragment shader and none for the old include", reloads)
+	}
+}
+
+func TestShaderCompileError(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	defer restore()
+	fake.CompileError = func(shaderType uint32, source string) string {
+		if strings.Contains(source, "broken") {
+			return "0:2(1): error: syntax error"
+		}
+		return ""
+	}
+
+	_, err := CreateShaderErr("#version 330 core\nbroken", gl.VERTEX_SHADER)
+	var compileErr *ShaderCompileError
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("got %v, want a compile error", err)
+	}
+	if compileErr.Path != "" || compileErr.Stage != gl.VERTEX_SHADER || compileErr.Log != "0:2(1): error: syntax error" {
+		t.Errorf("compile error = %+v", compileErr)
+	}
+	if !strings.HasPrefix(err.Error(), "Failed to compile vertex shader:") {
+		t.Errorf("message %q", err.Error())
+	}
+
+	//the log points at the file the line came from
+	files := fstest.MapFS{
+		"shader.vert":  {Data: []byte(testVertexShader)},
+		"broken.frag":  {Data: []byte("#version 330 core\nbroken\n")},
+		"invalid.frag": {Data: []byte("#version 330 core\n#include \"missing.glsl\"\n")},
+	}
+	_, err = LoadShaderFS(files, "broken.frag", gl.FRAGMENT_SHADER)
+	if !errors.As(err, &compileErr) {
+		t.Fatalf("got %v, want a compile error", err)
+	}
+	if compileErr.Path != "broken.frag" || compileErr.Stage != gl.FRAGMENT_SHADER ||
+		!strings.HasPrefix(compileErr.Log, "broken.frag:2") {
+		t.Errorf("compile error = %+v", compileErr)
+	}
+
+	_, err = CreateProgramFromStageFilesFS(files,
+		StageFile{gl.VERTEX_SHADER, "shader.vert"},
+		StageFile{gl.FRAGMENT_SHADER, "broken.frag"},
+	)
+	if !errors.As(err, &compileErr) || compileErr.Path != "broken.frag" {
+		t.Errorf("got %v from the program, want the fragment shader's compile error", err)
+	}
+	if _, err := LoadShaderFS(files, "invalid.frag", gl.FRAGMENT_SHADER); err == nil || errors.As(err, &compileErr) {
+		t.Errorf("got %v for a missing include, want a preprocessing error", err)
+	}
+
+	if live := fake.Live(ResourceShader); len(live) != 0 {
+		t.Errorf("failed compiles left shaders %v", live)
+	}
+	if live := fake.Live(ResourceProgram); len(live) != 0 {
+		t.Errorf("failed compiles left programs %v", live)
+	}
+}
+
+func TestLoadShaderPanics(t *testing.T) {
+	_, restore := UseRecordingGL()
+	defer restore()
+
+	path := filepath.Join(t.TempDir(), "missing.frag")
+	if _, err := LoadShaderErr(path, gl.FRAGMENT_SHADER); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v from LoadShaderErr, want a missing file", err)
+	}
+
+	defer func() {
+		err, ok := recover().(error)
+		if !ok || !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("LoadShader panicked with %v, want the missing file", err)
+		}
+	}()
+	LoadShader(path, gl.FRAGMENT_SHADER)
+}
+
+const testGeometryShader = `#version 330 core
+layout (triangles) in;
+layout (triangle_strip, max_vertices = 3) out;
+uniform float explode;
+void main() {
+	for (int i = 0; i < 3; i++) {
+		gl_Position = gl_in[i].gl_Position + vec4(0, 0, explode, 0);
+		EmitVertex();
+	}
+	EndPrimitive();
+}`
+
+func TestGeometryStageProgram(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	files := fstest.MapFS{
+		"shader.vert": {Data: []byte(testVertexShader)},
+		"shader.geom": {Data: []byte(testGeometryShader)},
+		"shader.frag": {Data: []byte(testFragmentShader)},
+	}
+	fromFiles, err := NewShaderFromStageFilesFS(files,
+		StageFile{gl.VERTEX_SHADER, "shader.vert"},
+		StageFile{gl.GEOMETRY_SHADER, "shader.geom"},
+		StageFile{gl.FRAGMENT_SHADER, "shader.frag"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(fromFiles.Delete)
+
+	//the order the stages are given in doesn't matter
+	embedded, err := NewEmbeddedShaderFromStages(
+		StageSource{Stage: gl.FRAGMENT_SHADER, Source: &ShaderSource{Source: testFragmentShader}},
+		StageSource{Stage: gl.GEOMETRY_SHADER, Source: &ShaderSource{Source: testGeometryShader}},
+		StageSource{Stage: gl.VERTEX_SHADER, Source: &ShaderSource{Source: testVertexShader}},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(embedded.Delete)
+
+	for _, id := range []ProgramID{fromFiles.id, embedded.id} {
+		var stages []uint32
+		for _, stage := range fake.Program(id).stages {
+			stages = append(stages, stage.Type)
+		}
+		if len(stages) != 3 || !slices.Contains(stages, gl.GEOMETRY_SHADER) {
+			t.Errorf("program %d was linked from stages %v, want a geometry stage", id, stages)
+		}
+	}
+	if _, ok := embedded.uniformInfo("explode"); !ok {
+		t.Errorf("the geometry shader's uniform wasn't reflected: %v", embedded.Uniforms())
+	}
+}
+
+func TestValidateStages(t *testing.T) {
+	const computeShader = 0x91B9
+	tests := []struct {
+		stages    []uint32
+		wantError string
+	}{
+		{nil, "at least one shader stage"},
+		{[]uint32{gl.VERTEX_SHADER, gl.FRAGMENT_SHADER, gl.VERTEX_SHADER}, "vertex stage is used more than once"},
+		{[]uint32{computeShader}, "unsupported shader stage unknown (0x91B9)"},
+	}
+	for _, test := range tests {
+		if err := validateStages(test.stages); err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Errorf("stages %v got error %v, want %q", test.stages, err, test.wantError)
+		}
+	}
+
+	if err := validateStages([]uint32{gl.FRAGMENT_SHADER, gl.GEOMETRY_SHADER, gl.VERTEX_SHADER}); err != nil {
+		t.Errorf("every stage once got %v", err)
+	}
+	if _, err := CreateProgramFromStageSources(); err == nil {
+		t.Error("a program with no stages was created")
+	}
+}
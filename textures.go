@@ -1,53 +1,51 @@
 package gogl
 
 import (
+	"fmt"
 	"image"
 	"image/png"
-	"os"
+	"io/fs"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/moltenwolfcub/gogl-utils/glsl"
 )
 
 type TextureID uint32
 
-func LoadTexture(filename string) TextureID {
-	file, err := os.Open(filename)
+// same as LoadTexture but returns the error rather than panicking
+func LoadTextureErr(filename string) (TextureID, error) {
+	return LoadTextureFS(glsl.OSFS{}, filename)
+}
+
+// same as LoadTextureErr but reads the png from fsys
+func LoadTextureFS(fsys fs.FS, filename string) (TextureID, error) {
+	file, err := fsys.Open(filename)
 	if err != nil {
-		panic(err)
+		return 0, err
 	}
 	defer file.Close()
 
 	img, err := png.Decode(file)
 	if err != nil {
-		panic(err)
+		return 0, fmt.Errorf("%s: %w", filename, err)
 	}
 
-	w := img.Bounds().Max.X
-	h := img.Bounds().Max.Y
+	return LoadTextureFromImage(img), nil
+}
 
-	pixels := make([]byte, w*h*4)
-	i := 0
-	for y := 0; y < h; y++ {
-		for x := 0; x < w; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
-			pixels[i] = byte(r / 256)
-			i++
-			pixels[i] = byte(g / 256)
-			i++
-			pixels[i] = byte(b / 256)
-			i++
-			pixels[i] = byte(a / 256)
-			i++
-		}
+func LoadTexture(filename string) TextureID {
+	texture, err := LoadTextureErr(filename)
+	if err != nil {
+		panic(err)
 	}
+	return texture
+}
 
-	texture := GenBindTexture()
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(w), int32(h), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+func MustLoadTextureFS(fsys fs.FS, filename string) TextureID {
+	texture, err := LoadTextureFS(fsys, filename)
+	if err != nil {
+		panic(err)
+	}
 	return texture
 }
 
@@ -73,24 +71,34 @@ func LoadTextureFromImage(img image.Image) TextureID {
 	}
 
 	texture := GenBindTexture()
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(w), int32(h), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
-	gl.GenerateMipmap(gl.TEXTURE_2D)
+	ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.REPEAT)
+	ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.REPEAT)
+	ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	ogl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	ogl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGBA, int32(w), int32(h), 0, gl.RGBA, gl.UNSIGNED_BYTE, gl.Ptr(pixels))
+	ogl.GenerateMipmap(gl.TEXTURE_2D)
 	return texture
 }
 
 // generates a new nexture ID and binds it to gl.TEXTURE_2D
 func GenBindTexture() TextureID {
 	var textureId uint32
-	gl.GenTextures(1, &textureId)
-	gl.BindTexture(gl.TEXTURE_2D, textureId)
+	ogl.GenTextures(1, &textureId)
+	ogl.BindTexture(gl.TEXTURE_2D, textureId)
+	trackResource(ResourceTexture, textureId)
 	return TextureID(textureId)
 }
 
+func (id TextureID) Delete() {
+	if id == 0 {
+		return
+	}
+	texture := uint32(id)
+	ogl.DeleteTextures(1, &texture)
+	untrackResource(ResourceTexture, texture)
+}
+
 // binds a texture to gl.TEXTURE_2D from its texture id
 func BindTexture(id TextureID) {
-	gl.BindTexture(gl.TEXTURE_2D, uint32(id))
+	ogl.BindTexture(gl.TEXTURE_2D, uint32(id))
 }
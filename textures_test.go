@@ -0,0 +1,58 @@
+package gogl
+
+import (
+	"image"
+	"image/color"
+	"slices"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+func TestLoadTextureFromImage(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+
+	img := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	img.Set(0, 0, color.NRGBA{255, 0, 0, 255})
+	img.Set(1, 0, color.NRGBA{0, 255, 0, 255})
+	img.Set(0, 1, color.NRGBA{0, 0, 255, 255})
+	img.Set(1, 1, color.NRGBA{255, 255, 255, 255})
+
+	id := LoadTextureFromImage(img)
+	t.Cleanup(id.Delete)
+
+	texture := fake.Texture(id)
+	if texture == nil {
+		t.Fatal("no texture was made")
+	}
+	if texture.Width != 2 || texture.Height != 2 || texture.InternalFormat != gl.RGBA ||
+		texture.Format != gl.RGBA || texture.Type != gl.UNSIGNED_BYTE {
+		t.Errorf("texture is %dx%d of 0x%X from 0x%X 0x%X", texture.Width, texture.Height,
+			texture.InternalFormat, texture.Format, texture.Type)
+	}
+
+	//the rows stay top to bottom
+	want := []byte{
+		255, 0, 0, 255, 0, 255, 0, 255,
+		0, 0, 255, 255, 255, 255, 255, 255,
+	}
+	if !slices.Equal(texture.Pixels, want) {
+		t.Errorf("pixels are %v, want %v", texture.Pixels, want)
+	}
+
+	params := map[uint32]int32{
+		gl.TEXTURE_WRAP_S:     gl.REPEAT,
+		gl.TEXTURE_WRAP_T:     gl.REPEAT,
+		gl.TEXTURE_MIN_FILTER: gl.LINEAR,
+		gl.TEXTURE_MAG_FILTER: gl.LINEAR,
+	}
+	for param, want := range params {
+		if got := texture.Params[param]; got != want {
+			t.Errorf("parameter 0x%X is 0x%X, want 0x%X", param, got, want)
+		}
+	}
+	if !texture.Mipmaps {
+		t.Error("mipmaps weren't generated")
+	}
+}
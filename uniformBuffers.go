@@ -0,0 +1,312 @@
+package gogl
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// a single value inside a std140 uniform block
+type Std140Field struct {
+	Name   string // e.g. "lights[1].pos"
+	Offset int
+	Size   int
+}
+
+var (
+	vec2Type  = reflect.TypeOf(mgl32.Vec2{})
+	vec3Type  = reflect.TypeOf(mgl32.Vec3{})
+	vec4Type  = reflect.TypeOf(mgl32.Vec4{})
+	mat2Type  = reflect.TypeOf(mgl32.Mat2{})
+	mat3Type  = reflect.TypeOf(mgl32.Mat3{})
+	mat4Type  = reflect.TypeOf(mgl32.Mat4{})
+	int32Type = reflect.TypeOf(int32(0))
+	uintType  = reflect.TypeOf(uint32(0))
+)
+
+// the number of components if t is one of the glsl vector types
+func std140VectorSize(t reflect.Type) (int, bool) {
+	switch t {
+	case vec2Type, vec3Type, vec4Type:
+		return t.Len(), true
+	}
+	if t.Kind() == reflect.Array && t.Name() == "" && t.Len() >= 2 && t.Len() <= 4 {
+		if elem := t.Elem(); elem == int32Type || elem == uintType {
+			return t.Len(), true
+		}
+	}
+	return 0, false
+}
+
+// the number of columns if t is one of the glsl matrix types
+func std140MatrixSize(t reflect.Type) (int, bool) {
+	switch t {
+	case mat2Type:
+		return 2, true
+	case mat3Type:
+		return 3, true
+	case mat4Type:
+		return 4, true
+	}
+	return 0, false
+}
+
+func roundUp(n, multiple int) int {
+	return (n + multiple - 1) / multiple * multiple
+}
+
+// the size and base alignment of a go type in a std140 block
+//
+// scalars are 4 bytes, vec2 is aligned to 8 and vec3/vec4 to 16.
+// arrays, matrix columns and structs are aligned to 16 and
+// array elements are padded to a multiple of 16
+func std140Layout(t reflect.Type) (size, align int, err error) {
+	if n, ok := std140VectorSize(t); ok {
+		if n == 2 {
+			return 8, 8, nil
+		}
+		return n * 4, 16, nil
+	}
+	if columns, ok := std140MatrixSize(t); ok {
+		return columns * 16, 16, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int32, reflect.Uint32, reflect.Float32, reflect.Int:
+		return 4, 4, nil
+	case reflect.Array:
+		elemSize, _, err := std140Layout(t.Elem())
+		if err != nil {
+			return 0, 0, err
+		}
+		return roundUp(elemSize, 16) * t.Len(), 16, nil
+	case reflect.Struct:
+		offset := 0
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := glslName(t.Field(i)); !ok {
+				continue
+			}
+			fieldSize, fieldAlign, err := std140Layout(t.Field(i).Type)
+			if err != nil {
+				return 0, 0, fmt.Errorf("%s.%s: %w", t, t.Field(i).Name, err)
+			}
+			offset = roundUp(offset, fieldAlign) + fieldSize
+		}
+		return roundUp(offset, 16), 16, nil
+	}
+	return 0, 0, fmt.Errorf("unsupported std140 type %s", t)
+}
+
+// lists the offset of every value in a struct laid out with std140
+// along with the total size of the block
+func Std140Fields(t reflect.Type) ([]Std140Field, int, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, 0, fmt.Errorf("%s isn't a struct", t)
+	}
+	size, _, err := std140Layout(t)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var fields []Std140Field
+	walkStd140(t, "", 0, func(name string, offset int, t reflect.Type) {
+		leafSize, _, _ := std140Layout(t)
+		fields = append(fields, Std140Field{
+			Name:   name,
+			Offset: offset,
+			Size:   leafSize,
+		})
+	})
+	return fields, size, nil
+}
+
+// calls leaf for every non struct, non array value in t
+// t must already have been checked with std140Layout
+func walkStd140(t reflect.Type, name string, offset int, leaf func(name string, offset int, t reflect.Type)) {
+	_, isVector := std140VectorSize(t)
+	_, isMatrix := std140MatrixSize(t)
+	switch {
+	case isVector || isMatrix:
+		leaf(name, offset, t)
+	case t.Kind() == reflect.Array:
+		elemSize, _, _ := std140Layout(t.Elem())
+		stride := roundUp(elemSize, 16)
+		for i := 0; i < t.Len(); i++ {
+			walkStd140(t.Elem(), fmt.Sprintf("%s[%d]", name, i), offset+i*stride, leaf)
+		}
+	case t.Kind() == reflect.Struct:
+		fieldOffset := 0
+		for i := 0; i < t.NumField(); i++ {
+			fieldName, ok := glslName(t.Field(i))
+			if !ok {
+				continue
+			}
+			if name != "" {
+				fieldName = name + "." + fieldName
+			}
+			fieldSize, fieldAlign, _ := std140Layout(t.Field(i).Type)
+			fieldOffset = roundUp(fieldOffset, fieldAlign)
+			walkStd140(t.Field(i).Type, fieldName, offset+fieldOffset, leaf)
+			fieldOffset += fieldSize
+		}
+	default:
+		leaf(name, offset, t)
+	}
+}
+
+// packs a struct into the bytes of a std140 uniform block
+func PackStd140(value any) ([]byte, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("can't pack a nil %T", value)
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%s isn't a struct", v.Type())
+	}
+
+	size, _, err := std140Layout(v.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	packStd140(buf, 0, v)
+	return buf, nil
+}
+
+func packStd140(buf []byte, offset int, v reflect.Value) {
+	t := v.Type()
+	if columns, ok := std140MatrixSize(t); ok {
+		//each column is padded out to a vec4
+		for c := 0; c < columns; c++ {
+			for r := 0; r < columns; r++ {
+				packStd140(buf, offset+c*16+r*4, v.Index(c*columns+r))
+			}
+		}
+		return
+	}
+	if n, ok := std140VectorSize(t); ok {
+		for i := 0; i < n; i++ {
+			packStd140(buf, offset+i*4, v.Index(i))
+		}
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			binary.LittleEndian.PutUint32(buf[offset:], 1)
+		}
+	case reflect.Int32, reflect.Int:
+		binary.LittleEndian.PutUint32(buf[offset:], uint32(int32(v.Int())))
+	case reflect.Uint32:
+		binary.LittleEndian.PutUint32(buf[offset:], uint32(v.Uint()))
+	case reflect.Float32:
+		binary.LittleEndian.PutUint32(buf[offset:], math.Float32bits(float32(v.Float())))
+	case reflect.Array:
+		elemSize, _, _ := std140Layout(t.Elem())
+		stride := roundUp(elemSize, 16)
+		for i := 0; i < v.Len(); i++ {
+			packStd140(buf, offset+i*stride, v.Index(i))
+		}
+	case reflect.Struct:
+		fieldOffset := 0
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := glslName(t.Field(i)); !ok {
+				continue
+			}
+			fieldSize, fieldAlign, _ := std140Layout(t.Field(i).Type)
+			fieldOffset = roundUp(fieldOffset, fieldAlign)
+			packStd140(buf, offset+fieldOffset, v.Field(i))
+			fieldOffset += fieldSize
+		}
+	}
+}
+
+// a uniform buffer holding a T packed with std140
+// bound to a binding point that uniform blocks can be linked to
+type UniformBuffer[T any] struct {
+	id      BufferID
+	binding uint32
+	size    int
+}
+
+func NewUniformBuffer[T any](binding uint32) (*UniformBuffer[T], error) {
+	var v T
+	size, _, err := std140Layout(reflect.TypeOf(v))
+	if err != nil {
+		return nil, err
+	}
+
+	u := UniformBuffer[T]{
+		binding: binding,
+		size:    size,
+	}
+	u.id = GenBindBuffer(gl.UNIFORM_BUFFER)
+	ogl.BufferData(gl.UNIFORM_BUFFER, size, nil, gl.DYNAMIC_DRAW)
+	ogl.BindBufferBase(gl.UNIFORM_BUFFER, binding, uint32(u.id))
+
+	return &u, nil
+}
+
+// packs value and uploads it to the buffer
+func (u *UniformBuffer[T]) Update(value T) error {
+	data, err := PackStd140(value)
+	if err != nil {
+		return err
+	}
+
+	ogl.BindBuffer(gl.UNIFORM_BUFFER, uint32(u.id))
+	ogl.BufferSubData(gl.UNIFORM_BUFFER, 0, len(data), gl.Ptr(data))
+	return nil
+}
+
+func (u *UniformBuffer[T]) Delete() {
+	u.id.Delete()
+	u.id = 0
+}
+
+func (u *UniformBuffer[T]) Binding() uint32 {
+	return u.binding
+}
+
+func (u *UniformBuffer[T]) ID() BufferID {
+	return u.id
+}
+
+// links the uniform block called blockName in shader to this buffer
+func (u *UniformBuffer[T]) BindBlock(shader Shader, blockName string) error {
+	return shader.BindUniformBlock(blockName, u.binding)
+}
+
+// links a uniform block to a binding point, the binding is
+// kept when the shader reloads
+func (p *program) BindUniformBlock(blockName string, binding uint32) error {
+	index := ogl.GetUniformBlockIndex(uint32(p.id), gl.Str(blockName+"\x00"))
+	if index == gl.INVALID_INDEX {
+		return fmt.Errorf("uniform block %s isn't active in the program", blockName)
+	}
+	ogl.UniformBlockBinding(uint32(p.id), index, binding)
+
+	if p.blockBindings == nil {
+		p.blockBindings = make(map[string]uint32)
+	}
+	p.blockBindings[blockName] = binding
+	return nil
+}
+
+func (p *program) restoreBlockBindings() {
+	for blockName, binding := range p.blockBindings {
+		index := ogl.GetUniformBlockIndex(uint32(p.id), gl.Str(blockName+"\x00"))
+		if index != gl.INVALID_INDEX {
+			ogl.UniformBlockBinding(uint32(p.id), index, binding)
+		}
+	}
+}
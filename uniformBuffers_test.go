@@ -0,0 +1,218 @@
+package gogl
+
+import (
+	"encoding/binary"
+	"math"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type testLight struct {
+	Pos   mgl32.Vec3
+	Color mgl32.Vec3
+}
+
+func TestStd140Fields(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  any
+		fields []Std140Field
+		size   int
+	}{
+		{
+			name: "vec3 then float shares the vec3's last 4 bytes",
+			value: struct {
+				Pos       mgl32.Vec3
+				Intensity float32
+			}{},
+			fields: []Std140Field{{"pos", 0, 12}, {"intensity", 12, 4}},
+			size:   16,
+		},
+		{
+			name: "vec2 is aligned to 8",
+			value: struct {
+				A float32
+				B mgl32.Vec2
+			}{},
+			fields: []Std140Field{{"a", 0, 4}, {"b", 8, 8}},
+			size:   16,
+		},
+		{
+			name: "scalar array has a 16 byte stride",
+			value: struct {
+				Weights [3]float32
+				After   float32
+			}{},
+			fields: []Std140Field{{"weights[0]", 0, 4}, {"weights[1]", 16, 4}, {"weights[2]", 32, 4}, {"after", 48, 4}},
+			size:   64,
+		},
+		{
+			name: "mat3 columns are padded to vec4",
+			value: struct {
+				Normal mgl32.Mat3
+				F      float32
+			}{},
+			fields: []Std140Field{{"normal", 0, 48}, {"f", 48, 4}},
+			size:   64,
+		},
+		{
+			name: "nested structs and arrays of structs",
+			value: struct {
+				Count  int32
+				Sun    testLight
+				Lights [2]testLight
+			}{},
+			fields: []Std140Field{
+				{"count", 0, 4},
+				{"sun.pos", 16, 12}, {"sun.color", 32, 12},
+				{"lights[0].pos", 48, 12}, {"lights[0].color", 64, 12},
+				{"lights[1].pos", 80, 12}, {"lights[1].color", 96, 12},
+			},
+			size: 112,
+		},
+		{
+			name: "struct end is padded to 16",
+			value: struct {
+				Inner struct{ X float32 }
+				Y     float32
+			}{},
+			fields: []Std140Field{{"inner.x", 0, 4}, {"y", 16, 4}},
+			size:   32,
+		},
+		{
+			name: "block end is padded to 16",
+			value: struct {
+				A mgl32.Vec2
+				B float32
+			}{},
+			fields: []Std140Field{{"a", 0, 8}, {"b", 8, 4}},
+			size:   16,
+		},
+		{
+			name: "tags rename and skip fields",
+			value: struct {
+				Ambient float32 `glsl:"ambientStrength"`
+				Skipped float32 `glsl:"-"`
+				hidden  float32
+				Ivec    [3]int32
+			}{},
+			fields: []Std140Field{{"ambientStrength", 0, 4}, {"ivec", 16, 12}},
+			size:   32,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fields, size, err := Std140Fields(reflect.TypeOf(test.value))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(fields, test.fields) {
+				t.Errorf("fields = %v, want %v", fields, test.fields)
+			}
+			if size != test.size {
+				t.Errorf("size = %d, want %d", size, test.size)
+			}
+
+			buf, err := PackStd140(test.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(buf) != test.size {
+				t.Errorf("packed %d bytes, want %d", len(buf), test.size)
+			}
+		})
+	}
+}
+
+func TestStd140FieldsErrors(t *testing.T) {
+	if _, _, err := Std140Fields(reflect.TypeOf(float32(0))); err == nil {
+		t.Error("a non struct should be an error")
+	}
+	if _, _, err := Std140Fields(reflect.TypeOf(struct{ D float64 }{})); err == nil {
+		t.Error("float64 should be an error")
+	}
+	if _, err := PackStd140(struct{ S []float32 }{}); err == nil {
+		t.Error("a slice should be an error")
+	}
+}
+
+func std140Float(buf []byte, offset int) float32 {
+	return math.Float32frombits(binary.LittleEndian.Uint32(buf[offset:]))
+}
+
+func TestPackStd140(t *testing.T) {
+	value := struct {
+		Pos     mgl32.Vec3
+		Scale   float32
+		Weights [2]float32
+		Normal  mgl32.Mat3
+		Enabled bool
+		Index   int32
+		Sun     testLight
+	}{
+		Pos:     mgl32.Vec3{1, 2, 3},
+		Scale:   4,
+		Weights: [2]float32{5, 6},
+		Normal:  mgl32.Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9},
+		Enabled: true,
+		Index:   -2,
+		Sun:     testLight{Pos: mgl32.Vec3{10, 11, 12}, Color: mgl32.Vec3{13, 14, 15}},
+	}
+
+	buf, err := PackStd140(&value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buf) != 144 {
+		t.Fatalf("packed %d bytes, want 144", len(buf))
+	}
+
+	floats := map[int]float32{
+		0: 1, 4: 2, 8: 3, 12: 4,
+		16: 5, 32: 6,
+		//each mat3 column starts on a new vec4
+		48: 1, 52: 2, 56: 3, 64: 4, 68: 5, 72: 6, 80: 7, 84: 8, 88: 9,
+		112: 10, 116: 11, 120: 12, 128: 13, 132: 14, 136: 15,
+	}
+	for offset, want := range floats {
+		if got := std140Float(buf, offset); got != want {
+			t.Errorf("float at %d = %v, want %v", offset, got, want)
+		}
+	}
+	if got := binary.LittleEndian.Uint32(buf[96:]); got != 1 {
+		t.Errorf("bool = %d, want 1", got)
+	}
+	if got := int32(binary.LittleEndian.Uint32(buf[100:])); got != -2 {
+		t.Errorf("int = %d, want -2", got)
+	}
+	//padding is left zeroed
+	for _, offset := range []int{20, 60, 76, 92, 124} {
+		if got := binary.LittleEndian.Uint32(buf[offset:]); got != 0 {
+			t.Errorf("padding at %d = %d, want 0", offset, got)
+		}
+	}
+}
+
+func TestPackStd140Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"nil", nil},
+		{"nil pointer", (*testLight)(nil)},
+		{"not a struct", 4},
+		{"unsupported field", struct{ Name string }{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if buf, err := PackStd140(test.value); err == nil {
+				t.Errorf("packed %v without an error", buf)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package gogl
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// sets every field of a struct as the uniform prefix.field
+// e.g. SetStruct("lights[2]", light) sets "lights[2].pos" etc
+//
+// the glsl name of a field comes from its glsl tag, or the field name
+// starting with a lower case letter if it has none. Fields tagged
+// glsl:"-" and unexported fields are skipped.
+// nested structs become prefix.field.inner and slices and arrays
+// become glsl arrays. [2]int32 to [4]uint32 are set as ivec and uvec types
+func (p *program) SetStruct(prefix string, value any) error {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return fmt.Errorf("can't set uniform %s from a nil pointer", prefix)
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("can't set uniform %s from %s, it isn't a struct", prefix, v.Type())
+	}
+
+	return p.setStructFields(prefix, v)
+}
+
+func (p *program) setStructFields(prefix string, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := glslName(field)
+		if !ok {
+			continue
+		}
+		if prefix != "" {
+			name = prefix + "." + name
+		}
+
+		if err := p.setUniformValue(name, v.Field(i)); err != nil {
+			return fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+	}
+	return nil
+}
+
+// picks the setter for a single value based on its go type
+func (p *program) setUniformValue(name string, v reflect.Value) error {
+	switch value := v.Interface().(type) {
+	case bool:
+		p.SetBool(name, value)
+	case int32:
+		p.SetInt(name, value)
+	case int:
+		p.SetInt(name, int32(value))
+	case uint32:
+		p.SetUint(name, value)
+	case float32:
+		p.SetFloat(name, value)
+	case float64:
+		p.SetFloat(name, float32(value))
+	case mgl32.Vec2:
+		p.SetVec2(name, value)
+	case mgl32.Vec3:
+		p.SetVec3(name, value)
+	case mgl32.Vec4:
+		p.SetVec4(name, value)
+	case [2]int32:
+		p.SetIVec2(name, value)
+	case [3]int32:
+		p.SetIVec3(name, value)
+	case [4]int32:
+		p.SetIVec4(name, value)
+	case [2]uint32:
+		p.SetUVec2(name, value)
+	case [3]uint32:
+		p.SetUVec3(name, value)
+	case [4]uint32:
+		p.SetUVec4(name, value)
+	case mgl32.Mat2:
+		p.SetMatrix2(name, value)
+	case mgl32.Mat3:
+		p.SetMatrix3(name, value)
+	case mgl32.Mat4:
+		p.SetMatrix4(name, value)
+
+	// slices of the basic types are uploaded in a single call
+	case []bool:
+		p.SetBoolArray(name, value)
+	case []int32:
+		p.SetIntArray(name, value)
+	case []uint32:
+		p.SetUintArray(name, value)
+	case []float32:
+		p.SetFloatArray(name, value)
+	case []mgl32.Vec2:
+		p.SetVec2Array(name, value)
+	case []mgl32.Vec3:
+		p.SetVec3Array(name, value)
+	case []mgl32.Vec4:
+		p.SetVec4Array(name, value)
+	case []mgl32.Mat2:
+		p.SetMatrix2Array(name, value)
+	case []mgl32.Mat3:
+		p.SetMatrix3Array(name, value)
+	case []mgl32.Mat4:
+		p.SetMatrix4Array(name, value)
+
+	default:
+		switch v.Kind() {
+		case reflect.Struct:
+			return p.setStructFields(name, v)
+		case reflect.Slice, reflect.Array:
+			for i := 0; i < v.Len(); i++ {
+				if err := p.setUniformValue(fmt.Sprintf("%s[%d]", name, i), v.Index(i)); err != nil {
+					return err
+				}
+			}
+		case reflect.Pointer:
+			if !v.IsNil() {
+				return p.setUniformValue(name, v.Elem())
+			}
+		default:
+			return fmt.Errorf("unsupported uniform type %s", v.Type())
+		}
+	}
+	return nil
+}
+
+// the glsl name of a struct field or false if it should be skipped
+func glslName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	name := field.Tag.Get("glsl")
+	if name == "-" {
+		return "", false
+	}
+	if name == "" {
+		name = glslFieldName(field.Name)
+	}
+	return name, true
+}
+
+// lower cases the leading capitals of a go name
+// e.g. Pos -> pos, DiffuseColor -> diffuseColor, UVScale -> uvScale
+func glslFieldName(name string) string {
+	runes := []rune(name)
+	upper := 0
+	for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+		upper++
+	}
+	if upper > 1 && upper < len(runes) {
+		upper-- //the last capital starts the next word
+	}
+	return strings.ToLower(string(runes[:upper])) + string(runes[upper:])
+}
@@ -0,0 +1,107 @@
+package gogl
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestSetStruct(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+	shader, err := NewEmbeddedShaderErr(testVertexShader, `#version 330 core
+struct Attenuation {
+	float linear;
+	float quadratic;
+};
+struct Light {
+	vec3 pos;
+	vec3 diffuseColor;
+	float strength[2];
+	Attenuation falloff;
+	bool enabled;
+};
+uniform Light lights[2];
+out vec4 color;
+void main() { color = vec4(lights[0].pos, 1); }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+	shader.Use()
+
+	type attenuation struct {
+		Linear    float32
+		Quadratic float32
+	}
+	type light struct {
+		Pos          mgl32.Vec3
+		DiffuseColor mgl32.Vec3
+		Strength     [2]float32
+		Attenuation  attenuation `glsl:"falloff"`
+		On           bool        `glsl:"enabled"`
+		Name         string      `glsl:"-"`
+		index        int
+	}
+	value := light{
+		Pos:          mgl32.Vec3{1, 2, 3},
+		DiffuseColor: mgl32.Vec3{0.5, 0.5, 0.5},
+		Strength:     [2]float32{4, 5},
+		Attenuation:  attenuation{0.1, 0.2},
+		On:           true,
+		Name:         "sun",
+	}
+	if err := shader.SetStruct("lights[1]", &value); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]any{
+		"lights[1].pos":               []float32{1, 2, 3},
+		"lights[1].diffuseColor":      []float32{0.5, 0.5, 0.5},
+		"lights[1].strength[0]":       []float32{4},
+		"lights[1].strength[1]":       []float32{5},
+		"lights[1].falloff.linear":    []float32{0.1},
+		"lights[1].falloff.quadratic": []float32{0.2},
+		"lights[1].enabled":           []int32{1},
+	}
+	uniforms := fake.Program(shader.id).Uniforms
+	for name, value := range want {
+		if got := uniforms[name]; !reflect.DeepEqual(got, value) {
+			t.Errorf("%s = %v, want %v", name, got, value)
+		}
+	}
+	if len(uniforms) != len(want) {
+		t.Errorf("set %d uniforms, want %d: %v", len(uniforms), len(want), uniforms)
+	}
+
+	errorTests := []struct {
+		value     any
+		wantError string
+	}{
+		{(*light)(nil), "nil pointer"},
+		{mgl32.Vec3{}, "isn't a struct"},
+		{struct{ Name string }{}, "unsupported uniform type string"},
+	}
+	for _, test := range errorTests {
+		if err := shader.SetStruct("lights[0]", test.value); err == nil || !strings.Contains(err.Error(), test.wantError) {
+			t.Errorf("setting %T got error %v, want %q", test.value, err, test.wantError)
+		}
+	}
+}
+
+func TestGLSLFieldName(t *testing.T) {
+	names := map[string]string{
+		"Pos":          "pos",
+		"DiffuseColor": "diffuseColor",
+		"UVScale":      "uvScale",
+		"ID":           "id",
+		"X":            "x",
+	}
+	for name, want := range names {
+		if got := glslFieldName(name); got != want {
+			t.Errorf("glslFieldName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
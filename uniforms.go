@@ -0,0 +1,485 @@
+package gogl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// an active uniform of a linked program
+type UniformInfo struct {
+	Name     string
+	Type     uint32 // the glsl type e.g. gl.FLOAT_VEC3
+	Size     int32  // the array length or 1 if it isn't an array
+	Location int32  // -1 for uniforms inside a uniform block
+}
+
+// queries every active uniform of a linked program
+// arrays are reported once with the name of their first element e.g. "lights[0]"
+func ReflectUniforms(id ProgramID) []UniformInfo {
+	var count, maxLength int32
+	ogl.GetProgramiv(uint32(id), gl.ACTIVE_UNIFORMS, &count)
+	ogl.GetProgramiv(uint32(id), gl.ACTIVE_UNIFORM_MAX_LENGTH, &maxLength)
+
+	uniforms := make([]UniformInfo, count)
+	nameBuf := make([]uint8, maxLength+1)
+	for i := range uniforms {
+		var length, size int32
+		var xtype uint32
+		ogl.GetActiveUniform(uint32(id), uint32(i), int32(len(nameBuf)), &length, &size, &xtype, &nameBuf[0])
+
+		name := string(nameBuf[:length])
+		uniforms[i] = UniformInfo{
+			Name:     name,
+			Type:     xtype,
+			Size:     size,
+			Location: ogl.GetUniformLocation(uint32(id), gl.Str(name+"\x00")),
+		}
+	}
+	return uniforms
+}
+
+// the state shared by every shader type
+// uniform locations are cached so setting a uniform doesn't
+// have to call glGetUniformLocation every time
+type program struct {
+	id         ProgramID
+	uniforms   []UniformInfo
+	attributes []AttributeInfo
+	infos      map[string]UniformInfo
+	locations  map[string]int32
+
+	onUniformError func(err error)
+	reported       map[string]bool
+
+	blockBindings map[string]uint32
+}
+
+func newProgram(id ProgramID) program {
+	p := program{}
+	p.setID(id)
+	return p
+}
+
+// frees the program and forgets its uniforms and attributes
+func (p *program) Delete() {
+	p.id.Delete()
+	p.id = 0
+	p.uniforms = nil
+	p.attributes = nil
+	clear(p.infos)
+	clear(p.locations)
+}
+
+// replaces the program, e.g. after a reload, and
+// rebuilds the uniform and attribute caches for the new program
+func (p *program) setID(id ProgramID) {
+	p.id = id
+	p.uniforms = ReflectUniforms(id)
+	p.attributes = ReflectAttributes(id)
+	p.infos = make(map[string]UniformInfo, len(p.uniforms))
+	p.locations = make(map[string]int32, len(p.uniforms))
+	p.reported = make(map[string]bool)
+	for _, u := range p.uniforms {
+		p.infos[u.Name] = u
+		p.locations[u.Name] = u.Location
+		//"lights[0]" can also be set as just "lights"
+		if base, ok := strings.CutSuffix(u.Name, "[0]"); ok {
+			p.infos[base] = u
+			p.locations[base] = u.Location
+		}
+	}
+	p.restoreBlockBindings()
+}
+
+// finds the reflected uniform for a name, elements of arrays
+// like "weights[3]" get the array's type and the remaining size
+func (p *program) uniformInfo(name string) (UniformInfo, bool) {
+	if info, ok := p.infos[name]; ok {
+		return info, true
+	}
+
+	match := arrayElementRegex.FindStringSubmatch(name)
+	if match == nil {
+		return UniformInfo{}, false
+	}
+	info, ok := p.infos[match[1]]
+	if !ok {
+		return UniformInfo{}, false
+	}
+	index, _ := strconv.Atoi(match[2])
+	info.Size -= int32(index)
+	return info, true
+}
+
+var arrayElementRegex = regexp.MustCompile(`^(.*)\[(\d+)\]$`)
+
+// names that aren't active uniforms, like the other elements of an
+// array, are looked up once and then cached (including when missing)
+func (p *program) location(name string) int32 {
+	if loc, ok := p.locations[name]; ok {
+		return loc
+	}
+
+	loc := ogl.GetUniformLocation(uint32(p.id), gl.Str(name+"\x00"))
+	p.locations[name] = loc
+	return loc
+}
+
+// the active uniforms of the current program
+func (p *program) Uniforms() []UniformInfo {
+	return p.uniforms
+}
+
+func (p *program) Use() {
+	UseProgram(p.id)
+}
+
+// returned when a uniform is set with a setter that doesn't
+// match its glsl type or with more elements than it has
+type UniformTypeError struct {
+	Name   string
+	Setter string
+	Type   uint32
+	Size   int32
+	Count  int
+}
+
+func (e *UniformTypeError) Error() string {
+	if e.Count > int(e.Size) {
+		return fmt.Sprintf("uniform %s has %d elements but %s set %d", e.Name, e.Size, e.Setter, e.Count)
+	}
+	return fmt.Sprintf("uniform %s is a %s and can't be set with %s", e.Name, UniformTypeName(e.Type), e.Setter)
+}
+
+// callback is run the first time each uniform is set with the wrong type
+// by default the error is printed
+func (p *program) SetUniformErrorCallback(callback func(err error)) {
+	p.onUniformError = callback
+}
+
+// looks up the location of a uniform and checks that the setter
+// and number of elements match what the program declares
+func (p *program) uniform(name, setter string, count int, types []uint32) int32 {
+	loc := p.location(name)
+	if loc < 0 {
+		return loc //optimised out or misspelled, gl ignores location -1
+	}
+
+	info, ok := p.uniformInfo(name)
+	if !ok || (slices.Contains(types, info.Type) && count <= int(info.Size)) {
+		return loc
+	}
+
+	if !p.reported[name] {
+		p.reported[name] = true
+		err := &UniformTypeError{
+			Name:   name,
+			Setter: setter,
+			Type:   info.Type,
+			Size:   info.Size,
+			Count:  count,
+		}
+		if p.onUniformError != nil {
+			p.onUniformError(err)
+		} else {
+			fmt.Println(err)
+		}
+	}
+	return -1
+}
+
+var (
+	samplerTypes = []uint32{
+		gl.SAMPLER_1D, gl.SAMPLER_2D, gl.SAMPLER_3D, gl.SAMPLER_CUBE,
+		gl.SAMPLER_1D_SHADOW, gl.SAMPLER_2D_SHADOW, gl.SAMPLER_CUBE_SHADOW,
+		gl.SAMPLER_1D_ARRAY, gl.SAMPLER_2D_ARRAY, gl.SAMPLER_1D_ARRAY_SHADOW, gl.SAMPLER_2D_ARRAY_SHADOW,
+		gl.SAMPLER_2D_MULTISAMPLE, gl.SAMPLER_2D_MULTISAMPLE_ARRAY,
+		gl.SAMPLER_BUFFER, gl.SAMPLER_2D_RECT, gl.SAMPLER_2D_RECT_SHADOW,
+		gl.INT_SAMPLER_1D, gl.INT_SAMPLER_2D, gl.INT_SAMPLER_3D, gl.INT_SAMPLER_CUBE,
+		gl.INT_SAMPLER_1D_ARRAY, gl.INT_SAMPLER_2D_ARRAY,
+		gl.INT_SAMPLER_2D_MULTISAMPLE, gl.INT_SAMPLER_2D_MULTISAMPLE_ARRAY,
+		gl.INT_SAMPLER_BUFFER, gl.INT_SAMPLER_2D_RECT,
+		gl.UNSIGNED_INT_SAMPLER_1D, gl.UNSIGNED_INT_SAMPLER_2D, gl.UNSIGNED_INT_SAMPLER_3D, gl.UNSIGNED_INT_SAMPLER_CUBE,
+		gl.UNSIGNED_INT_SAMPLER_1D_ARRAY, gl.UNSIGNED_INT_SAMPLER_2D_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE, gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE_ARRAY,
+		gl.UNSIGNED_INT_SAMPLER_BUFFER, gl.UNSIGNED_INT_SAMPLER_2D_RECT,
+	}
+
+	//bools can be set with any of the scalar setters
+	boolTypes  = []uint32{gl.BOOL}
+	intTypes   = append([]uint32{gl.INT, gl.BOOL}, samplerTypes...)
+	uintTypes  = []uint32{gl.UNSIGNED_INT, gl.BOOL}
+	floatTypes = []uint32{gl.FLOAT, gl.BOOL}
+
+	vec2Types  = []uint32{gl.FLOAT_VEC2, gl.BOOL_VEC2}
+	vec3Types  = []uint32{gl.FLOAT_VEC3, gl.BOOL_VEC3}
+	vec4Types  = []uint32{gl.FLOAT_VEC4, gl.BOOL_VEC4}
+	ivec2Types = []uint32{gl.INT_VEC2, gl.BOOL_VEC2}
+	ivec3Types = []uint32{gl.INT_VEC3, gl.BOOL_VEC3}
+	ivec4Types = []uint32{gl.INT_VEC4, gl.BOOL_VEC4}
+	uvec2Types = []uint32{gl.UNSIGNED_INT_VEC2, gl.BOOL_VEC2}
+	uvec3Types = []uint32{gl.UNSIGNED_INT_VEC3, gl.BOOL_VEC3}
+	uvec4Types = []uint32{gl.UNSIGNED_INT_VEC4, gl.BOOL_VEC4}
+
+	mat2Types = []uint32{gl.FLOAT_MAT2}
+	mat3Types = []uint32{gl.FLOAT_MAT3}
+	mat4Types = []uint32{gl.FLOAT_MAT4}
+)
+
+var uniformTypeNames = map[uint32]string{
+	gl.BOOL: "bool", gl.INT: "int", gl.UNSIGNED_INT: "uint", gl.FLOAT: "float", gl.DOUBLE: "double",
+	gl.BOOL_VEC2: "bvec2", gl.BOOL_VEC3: "bvec3", gl.BOOL_VEC4: "bvec4",
+	gl.INT_VEC2: "ivec2", gl.INT_VEC3: "ivec3", gl.INT_VEC4: "ivec4",
+	gl.UNSIGNED_INT_VEC2: "uvec2", gl.UNSIGNED_INT_VEC3: "uvec3", gl.UNSIGNED_INT_VEC4: "uvec4",
+	gl.FLOAT_VEC2: "vec2", gl.FLOAT_VEC3: "vec3", gl.FLOAT_VEC4: "vec4",
+	gl.FLOAT_MAT2: "mat2", gl.FLOAT_MAT3: "mat3", gl.FLOAT_MAT4: "mat4",
+	gl.FLOAT_MAT2x3: "mat2x3", gl.FLOAT_MAT2x4: "mat2x4", gl.FLOAT_MAT3x2: "mat3x2",
+	gl.FLOAT_MAT3x4: "mat3x4", gl.FLOAT_MAT4x2: "mat4x2", gl.FLOAT_MAT4x3: "mat4x3",
+	gl.SAMPLER_1D: "sampler1D", gl.SAMPLER_2D: "sampler2D", gl.SAMPLER_3D: "sampler3D", gl.SAMPLER_CUBE: "samplerCube",
+	gl.SAMPLER_1D_SHADOW: "sampler1DShadow", gl.SAMPLER_2D_SHADOW: "sampler2DShadow", gl.SAMPLER_CUBE_SHADOW: "samplerCubeShadow",
+	gl.SAMPLER_1D_ARRAY: "sampler1DArray", gl.SAMPLER_2D_ARRAY: "sampler2DArray",
+	gl.SAMPLER_1D_ARRAY_SHADOW: "sampler1DArrayShadow", gl.SAMPLER_2D_ARRAY_SHADOW: "sampler2DArrayShadow",
+	gl.SAMPLER_2D_MULTISAMPLE: "sampler2DMS", gl.SAMPLER_2D_MULTISAMPLE_ARRAY: "sampler2DMSArray",
+	gl.SAMPLER_BUFFER: "samplerBuffer", gl.SAMPLER_2D_RECT: "sampler2DRect", gl.SAMPLER_2D_RECT_SHADOW: "sampler2DRectShadow",
+	gl.INT_SAMPLER_1D: "isampler1D", gl.INT_SAMPLER_2D: "isampler2D", gl.INT_SAMPLER_3D: "isampler3D", gl.INT_SAMPLER_CUBE: "isamplerCube",
+	gl.INT_SAMPLER_1D_ARRAY: "isampler1DArray", gl.INT_SAMPLER_2D_ARRAY: "isampler2DArray",
+	gl.INT_SAMPLER_2D_MULTISAMPLE: "isampler2DMS", gl.INT_SAMPLER_2D_MULTISAMPLE_ARRAY: "isampler2DMSArray",
+	gl.INT_SAMPLER_BUFFER: "isamplerBuffer", gl.INT_SAMPLER_2D_RECT: "isampler2DRect",
+	gl.UNSIGNED_INT_SAMPLER_1D: "usampler1D", gl.UNSIGNED_INT_SAMPLER_2D: "usampler2D", gl.UNSIGNED_INT_SAMPLER_3D: "usampler3D", gl.UNSIGNED_INT_SAMPLER_CUBE: "usamplerCube",
+	gl.UNSIGNED_INT_SAMPLER_1D_ARRAY: "usampler1DArray", gl.UNSIGNED_INT_SAMPLER_2D_ARRAY: "usampler2DArray",
+	gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE: "usampler2DMS", gl.UNSIGNED_INT_SAMPLER_2D_MULTISAMPLE_ARRAY: "usampler2DMSArray",
+	gl.UNSIGNED_INT_SAMPLER_BUFFER: "usamplerBuffer", gl.UNSIGNED_INT_SAMPLER_2D_RECT: "usampler2DRect",
+}
+
+// the glsl name of a uniform type e.g. "vec3" for gl.FLOAT_VEC3
+func UniformTypeName(xtype uint32) string {
+	if name, ok := uniformTypeNames[xtype]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown (0x%X)", xtype)
+}
+
+func boolToInt32(value bool) int32 {
+	if value {
+		return 1
+	}
+	return 0
+}
+
+func (p *program) SetBool(name string, value bool) {
+	loc := p.uniform(name, "SetBool", 1, boolTypes)
+
+	ogl.Uniform1i(loc, boolToInt32(value))
+}
+func (p *program) SetInt(name string, value int32) {
+	loc := p.uniform(name, "SetInt", 1, intTypes)
+
+	ogl.Uniform1i(loc, value)
+}
+func (p *program) SetUint(name string, value uint32) {
+	loc := p.uniform(name, "SetUint", 1, uintTypes)
+
+	ogl.Uniform1ui(loc, value)
+}
+func (p *program) SetFloat(name string, value float32) {
+	loc := p.uniform(name, "SetFloat", 1, floatTypes)
+
+	ogl.Uniform1f(loc, value)
+}
+
+// sets a sampler uniform to read from texture unit
+// e.g. 0 for gl.TEXTURE0
+func (p *program) SetSampler(name string, unit int32) {
+	loc := p.uniform(name, "SetSampler", 1, samplerTypes)
+
+	ogl.Uniform1i(loc, unit)
+}
+
+func (p *program) SetVec2(name string, value mgl32.Vec2) {
+	loc := p.uniform(name, "SetVec2", 1, vec2Types)
+
+	ogl.Uniform2f(loc, value[0], value[1])
+}
+func (p *program) SetVec3(name string, value mgl32.Vec3) {
+	loc := p.uniform(name, "SetVec3", 1, vec3Types)
+
+	v3 := [3]float32(value)
+	ogl.Uniform3fv(loc, 1, &v3[0])
+}
+func (p *program) SetVec4(name string, value mgl32.Vec4) {
+	loc := p.uniform(name, "SetVec4", 1, vec4Types)
+
+	ogl.Uniform4f(loc, value[0], value[1], value[2], value[3])
+}
+
+func (p *program) SetIVec2(name string, value [2]int32) {
+	loc := p.uniform(name, "SetIVec2", 1, ivec2Types)
+
+	ogl.Uniform2i(loc, value[0], value[1])
+}
+func (p *program) SetIVec3(name string, value [3]int32) {
+	loc := p.uniform(name, "SetIVec3", 1, ivec3Types)
+
+	ogl.Uniform3i(loc, value[0], value[1], value[2])
+}
+func (p *program) SetIVec4(name string, value [4]int32) {
+	loc := p.uniform(name, "SetIVec4", 1, ivec4Types)
+
+	ogl.Uniform4i(loc, value[0], value[1], value[2], value[3])
+}
+
+func (p *program) SetUVec2(name string, value [2]uint32) {
+	loc := p.uniform(name, "SetUVec2", 1, uvec2Types)
+
+	ogl.Uniform2ui(loc, value[0], value[1])
+}
+func (p *program) SetUVec3(name string, value [3]uint32) {
+	loc := p.uniform(name, "SetUVec3", 1, uvec3Types)
+
+	ogl.Uniform3ui(loc, value[0], value[1], value[2])
+}
+func (p *program) SetUVec4(name string, value [4]uint32) {
+	loc := p.uniform(name, "SetUVec4", 1, uvec4Types)
+
+	ogl.Uniform4ui(loc, value[0], value[1], value[2], value[3])
+}
+
+func (p *program) SetMatrix2(name string, value mgl32.Mat2) {
+	loc := p.uniform(name, "SetMatrix2", 1, mat2Types)
+
+	m2 := [4]float32(value)
+	ogl.UniformMatrix2fv(loc, 1, false, &m2[0])
+}
+func (p *program) SetMatrix3(name string, value mgl32.Mat3) {
+	loc := p.uniform(name, "SetMatrix3", 1, mat3Types)
+
+	m3 := [9]float32(value)
+	ogl.UniformMatrix3fv(loc, 1, false, &m3[0])
+}
+func (p *program) SetMatrix4(name string, value mgl32.Mat4) {
+	loc := p.uniform(name, "SetMatrix4", 1, mat4Types)
+
+	m4 := [16]float32(value)
+	ogl.UniformMatrix4fv(loc, 1, false, &m4[0])
+}
+
+// the array setters upload every element in one call starting at name
+// which can be the array itself or an element e.g. "lights[2]"
+
+func (p *program) SetBoolArray(name string, values []bool) {
+	ints := make([]int32, len(values))
+	for i, v := range values {
+		ints[i] = boolToInt32(v)
+	}
+
+	loc := p.uniform(name, "SetBoolArray", len(values), boolTypes)
+	if len(ints) > 0 {
+		ogl.Uniform1iv(loc, int32(len(ints)), &ints[0])
+	}
+}
+func (p *program) SetIntArray(name string, values []int32) {
+	loc := p.uniform(name, "SetIntArray", len(values), intTypes)
+	if len(values) > 0 {
+		ogl.Uniform1iv(loc, int32(len(values)), &values[0])
+	}
+}
+func (p *program) SetUintArray(name string, values []uint32) {
+	loc := p.uniform(name, "SetUintArray", len(values), uintTypes)
+	if len(values) > 0 {
+		ogl.Uniform1uiv(loc, int32(len(values)), &values[0])
+	}
+}
+func (p *program) SetFloatArray(name string, values []float32) {
+	loc := p.uniform(name, "SetFloatArray", len(values), floatTypes)
+	if len(values) > 0 {
+		ogl.Uniform1fv(loc, int32(len(values)), &values[0])
+	}
+}
+func (p *program) SetSamplerArray(name string, units []int32) {
+	loc := p.uniform(name, "SetSamplerArray", len(units), samplerTypes)
+	if len(units) > 0 {
+		ogl.Uniform1iv(loc, int32(len(units)), &units[0])
+	}
+}
+
+func (p *program) SetVec2Array(name string, values []mgl32.Vec2) {
+	loc := p.uniform(name, "SetVec2Array", len(values), vec2Types)
+	if len(values) > 0 {
+		ogl.Uniform2fv(loc, int32(len(values)), &values[0][0])
+	}
+}
+func (p *program) SetVec3Array(name string, values []mgl32.Vec3) {
+	loc := p.uniform(name, "SetVec3Array", len(values), vec3Types)
+	if len(values) > 0 {
+		ogl.Uniform3fv(loc, int32(len(values)), &values[0][0])
+	}
+}
+func (p *program) SetVec4Array(name string, values []mgl32.Vec4) {
+	loc := p.uniform(name, "SetVec4Array", len(values), vec4Types)
+	if len(values) > 0 {
+		ogl.Uniform4fv(loc, int32(len(values)), &values[0][0])
+	}
+}
+
+func (p *program) SetIVec2Array(name string, values [][2]int32) {
+	loc := p.uniform(name, "SetIVec2Array", len(values), ivec2Types)
+	if len(values) > 0 {
+		ogl.Uniform2iv(loc, int32(len(values)), &values[0][0])
+	}
+}
+func (p *program) SetIVec3Array(name string, values [][3]int32) {
+	loc := p.uniform(name, "SetIVec3Array", len(values), ivec3Types)
+	if len(values) > 0 {
+		ogl.Uniform3iv(loc, int32(len(values)), &values[0][0])
+	}
+}
+func (p *program) SetIVec4Array(name string, values [][4]int32) {
+	loc := p.uniform(name, "SetIVec4Array", len(values), ivec4Types)
+	if len(values) > 0 {
+		ogl.Uniform4iv(loc, int32(len(values)), &values[0][0])
+	}
+}
+
+func (p *program) SetUVec2Array(name string, values [][2]uint32) {
+	loc := p.uniform(name, "SetUVec2Array", len(values), uvec2Types)
+	if len(values) > 0 {
+		ogl.Uniform2uiv(loc, int32(len(values)), &values[0][0])
+	}
+}
+func (p *program) SetUVec3Array(name string, values [][3]uint32) {
+	loc := p.uniform(name, "SetUVec3Array", len(values), uvec3Types)
+	if len(values) > 0 {
+		ogl.Uniform3uiv(loc, int32(len(values)), &values[0][0])
+	}
+}
+func (p *program) SetUVec4Array(name string, values [][4]uint32) {
+	loc := p.uniform(name, "SetUVec4Array", len(values), uvec4Types)
+	if len(values) > 0 {
+		ogl.Uniform4uiv(loc, int32(len(values)), &values[0][0])
+	}
+}
+
+func (p *program) SetMatrix2Array(name string, values []mgl32.Mat2) {
+	loc := p.uniform(name, "SetMatrix2Array", len(values), mat2Types)
+	if len(values) > 0 {
+		ogl.UniformMatrix2fv(loc, int32(len(values)), false, &values[0][0])
+	}
+}
+func (p *program) SetMatrix3Array(name string, values []mgl32.Mat3) {
+	loc := p.uniform(name, "SetMatrix3Array", len(values), mat3Types)
+	if len(values) > 0 {
+		ogl.UniformMatrix3fv(loc, int32(len(values)), false, &values[0][0])
+	}
+}
+func (p *program) SetMatrix4Array(name string, values []mgl32.Mat4) {
+	loc := p.uniform(name, "SetMatrix4Array", len(values), mat4Types)
+	if len(values) > 0 {
+		ogl.UniformMatrix4fv(loc, int32(len(values)), false, &values[0][0])
+	}
+}
@@ -0,0 +1,321 @@
+package gogl
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestReflectUniforms(t *testing.T) {
+	tests := []struct {
+		name     string
+		fragment string
+		want     []UniformInfo
+	}{
+		{
+			name:     "vertex only",
+			fragment: testFragmentShader,
+			want:     []UniformInfo{{"model", gl.FLOAT_MAT4, 1, 0}},
+		},
+		{
+			name: "shared and scalar arrays",
+			fragment: `#version 330 core
+uniform mat4 model;
+uniform float weights[4];
+uniform sampler2D tex, normals;
+out vec4 color;
+void main() { color = vec4(weights[0]); }`,
+			want: []UniformInfo{
+				{"model", gl.FLOAT_MAT4, 1, 0},
+				{"weights[0]", gl.FLOAT, 4, 1},
+				{"tex", gl.SAMPLER_2D, 1, 5},
+				{"normals", gl.SAMPLER_2D, 1, 6},
+			},
+		},
+		{
+			name: "structs",
+			fragment: `#version 330 core
+struct Light {
+	vec3 position;
+	float strength[2];
+};
+uniform Light sun;
+uniform Light lamps[2];
+uniform vec3 tint = vec3(1, 0.5, 0);
+out vec4 color;
+void main() { color = vec4(tint, 1); }`,
+			want: []UniformInfo{
+				{"model", gl.FLOAT_MAT4, 1, 0},
+				{"sun.position", gl.FLOAT_VEC3, 1, 1},
+				{"sun.strength[0]", gl.FLOAT, 2, 2},
+				{"lamps[0].position", gl.FLOAT_VEC3, 1, 4},
+				{"lamps[0].strength[0]", gl.FLOAT, 2, 5},
+				{"lamps[1].position", gl.FLOAT_VEC3, 1, 7},
+				{"lamps[1].strength[0]", gl.FLOAT, 2, 8},
+				{"tint", gl.FLOAT_VEC3, 1, 10},
+			},
+		},
+		{
+			name: "uniform block",
+			fragment: `#version 330 core
+layout (std140) uniform Camera {
+	mat4 view;
+	vec3 eye;
+};
+out vec4 color;
+void main() { color = vec4(eye, 1); }`,
+			want: []UniformInfo{
+				{"model", gl.FLOAT_MAT4, 1, 0},
+				{"view", gl.FLOAT_MAT4, 1, -1},
+				{"eye", gl.FLOAT_VEC3, 1, -1},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, restore := UseRecordingGL()
+			t.Cleanup(restore)
+
+			id, err := CreateProgramFromShadersErr(testVertexShader, test.fragment)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer id.Delete()
+
+			if got := ReflectUniforms(id); !slices.Equal(got, test.want) {
+				t.Errorf("got %v\nwant %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestUniformSetters(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+	embedded, err := NewEmbeddedShaderErr(testVertexShader, `#version 330 core
+uniform bool b;
+uniform int i;
+uniform uint u;
+uniform float f;
+uniform sampler2D tex;
+uniform vec2 v2;
+uniform vec3 v3;
+uniform vec4 v4;
+uniform ivec2 iv2;
+uniform ivec3 iv3;
+uniform ivec4 iv4;
+uniform uvec2 uv2;
+uniform uvec3 uv3;
+uniform uvec4 uv4;
+uniform mat2 m2;
+uniform mat3 m3;
+uniform bool bs[2];
+uniform int is[2];
+uniform uint us[2];
+uniform float fs[2];
+uniform sampler2D texs[2];
+uniform vec2 v2s[2];
+uniform vec4 v4s[2];
+uniform ivec3 iv3s[2];
+uniform uvec4 uv4s[2];
+uniform mat3 m3s[2];
+uniform mat4 m4s[2];
+out vec4 color;
+void main() { color = vec4(f); }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(embedded.Delete)
+
+	//every setter through the interface
+	var shader Shader = embedded
+	var reported []error
+	shader.SetUniformErrorCallback(func(err error) {
+		reported = append(reported, err)
+	})
+	shader.Use()
+
+	m3 := mgl32.Mat3{1, 2, 3, 4, 5, 6, 7, 8, 9}
+	m4 := mgl32.Translate3D(1, 2, 3)
+	tests := []struct {
+		set  func()
+		name string
+		want any
+	}{
+		{func() { shader.SetBool("b", true) }, "b", []int32{1}},
+		{func() { shader.SetInt("i", -2) }, "i", []int32{-2}},
+		{func() { shader.SetUint("u", 3) }, "u", []uint32{3}},
+		{func() { shader.SetFloat("f", 0.5) }, "f", []float32{0.5}},
+		{func() { shader.SetSampler("tex", 4) }, "tex", []int32{4}},
+		{func() { shader.SetVec2("v2", mgl32.Vec2{1, 2}) }, "v2", []float32{1, 2}},
+		{func() { shader.SetVec3("v3", mgl32.Vec3{1, 2, 3}) }, "v3", []float32{1, 2, 3}},
+		{func() { shader.SetVec4("v4", mgl32.Vec4{1, 2, 3, 4}) }, "v4", []float32{1, 2, 3, 4}},
+		{func() { shader.SetIVec2("iv2", [2]int32{1, -2}) }, "iv2", []int32{1, -2}},
+		{func() { shader.SetIVec3("iv3", [3]int32{1, -2, 3}) }, "iv3", []int32{1, -2, 3}},
+		{func() { shader.SetIVec4("iv4", [4]int32{1, -2, 3, -4}) }, "iv4", []int32{1, -2, 3, -4}},
+		{func() { shader.SetUVec2("uv2", [2]uint32{1, 2}) }, "uv2", []uint32{1, 2}},
+		{func() { shader.SetUVec3("uv3", [3]uint32{1, 2, 3}) }, "uv3", []uint32{1, 2, 3}},
+		{func() { shader.SetUVec4("uv4", [4]uint32{1, 2, 3, 4}) }, "uv4", []uint32{1, 2, 3, 4}},
+		{func() { shader.SetMatrix2("m2", mgl32.Mat2{1, 2, 3, 4}) }, "m2", []float32{1, 2, 3, 4}},
+		{func() { shader.SetMatrix3("m3", m3) }, "m3", m3[:]},
+		{func() { shader.SetMatrix4("model", m4) }, "model", m4[:]},
+
+		{func() { shader.SetBoolArray("bs", []bool{true, false}) }, "bs[0]", []int32{1, 0}},
+		{func() { shader.SetIntArray("is", []int32{1, 2}) }, "is[0]", []int32{1, 2}},
+		{func() { shader.SetUintArray("us", []uint32{1, 2}) }, "us[0]", []uint32{1, 2}},
+		{func() { shader.SetFloatArray("fs[1]", []float32{2}) }, "fs[1]", []float32{2}},
+		{func() { shader.SetSamplerArray("texs", []int32{0, 1}) }, "texs[0]", []int32{0, 1}},
+		{func() { shader.SetVec2Array("v2s", []mgl32.Vec2{{1, 2}, {3, 4}}) }, "v2s[0]", []float32{1, 2, 3, 4}},
+		{func() { shader.SetVec4Array("v4s", []mgl32.Vec4{{1, 2, 3, 4}}) }, "v4s[0]", []float32{1, 2, 3, 4}},
+		{func() { shader.SetIVec3Array("iv3s", [][3]int32{{1, 2, 3}, {4, 5, 6}}) }, "iv3s[0]", []int32{1, 2, 3, 4, 5, 6}},
+		{func() { shader.SetUVec4Array("uv4s", [][4]uint32{{1, 2, 3, 4}}) }, "uv4s[0]", []uint32{1, 2, 3, 4}},
+		{func() { shader.SetMatrix3Array("m3s[1]", []mgl32.Mat3{m3}) }, "m3s[1]", m3[:]},
+		{func() { shader.SetMatrix4Array("m4s", make([]mgl32.Mat4, 2)) }, "m4s[0]", make([]float32, 32)},
+	}
+	for _, test := range tests {
+		test.set()
+		if got := fake.Program(embedded.id).Uniforms[test.name]; !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s = %v, want %v", test.name, got, test.want)
+		}
+	}
+	if len(reported) != 0 {
+		t.Errorf("setters of the right type reported %v", reported)
+	}
+	if len(fake.Errors) != 0 {
+		t.Errorf("gl errors %v", fake.Errors)
+	}
+}
+
+func TestUniformLocationCache(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+	shader, err := NewEmbeddedShaderErr(testVertexShader, `#version 330 core
+uniform float brightness;
+uniform float weights[4];
+out vec4 color;
+void main() { color = vec4(weights[0] * brightness); }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+	shader.Use()
+
+	fake.Reset()
+	for i := 0; i < 2; i++ {
+		shader.SetFloat("brightness", 1)
+		shader.SetFloatArray("weights", []float32{1, 2})
+		shader.SetFloat("weights[2]", 3)
+		shader.SetFloat("missing", 4)
+	}
+
+	//the active uniforms were cached when the shader was built and
+	//the other names are only looked up the first time they're set
+	var lookups []string
+	for _, call := range fake.CallsTo("GetUniformLocation") {
+		lookups = append(lookups, call.Args[1].(string))
+	}
+	if !slices.Equal(lookups, []string{"weights[2]", "missing"}) {
+		t.Errorf("looked up %v, want weights[2] and missing once each", lookups)
+	}
+
+	sets := fake.CallsTo("Uniform1f")
+	if len(sets) != 6 {
+		t.Fatalf("%d Uniform1f calls, want 6", len(sets))
+	}
+	weights, _ := shader.uniformInfo("weights")
+	if sets[1].Args[0] != weights.Location+2 {
+		t.Errorf("weights[2] was set at %v, want %d", sets[1].Args[0], weights.Location+2)
+	}
+	if sets[2].Args[0] != int32(-1) {
+		t.Errorf("missing was set at %v, want -1", sets[2].Args[0])
+	}
+}
+
+func TestUniformBlockIndex(t *testing.T) {
+	_, restore := UseRecordingGL()
+	t.Cleanup(restore)
+	shader := testShader(t, `#version 330 core
+layout (location = 0) in vec3 aPos;
+layout (std140) uniform Camera { mat4 view; };
+void main() { gl_Position = view * vec4(aPos, 1); }`)
+
+	if err := shader.BindUniformBlock("Camera", 2); err != nil {
+		t.Errorf("binding a declared block: %v", err)
+	}
+	if err := shader.BindUniformBlock("Lights", 3); err == nil {
+		t.Error("binding a block the shader doesn't declare didn't fail")
+	}
+}
+
+func TestUniformTypeErrors(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore)
+	shader, err := NewEmbeddedShaderErr(testVertexShader, `#version 330 core
+uniform float brightness;
+uniform vec3 colors[2];
+uniform sampler2D tex;
+out vec4 color;
+void main() { color = vec4(colors[0] * brightness, 1); }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(shader.Delete)
+
+	var reported []error
+	shader.SetUniformErrorCallback(func(err error) {
+		reported = append(reported, err)
+	})
+	shader.Use()
+
+	shader.SetFloat("brightness", 2)
+	shader.SetVec3Array("colors", []mgl32.Vec3{{1, 0, 0}, {0, 1, 0}})
+	shader.SetVec3("colors[1]", mgl32.Vec3{0, 0, 1})
+	shader.SetSampler("tex", 3)
+	shader.SetMatrix4("model", mgl32.Ident4())
+	if len(reported) != 0 {
+		t.Fatalf("correct setters reported %v", reported)
+	}
+
+	uniforms := fake.Program(shader.id).Uniforms
+	if got := uniforms["brightness"]; !slices.Equal(got.([]float32), []float32{2}) {
+		t.Errorf("brightness = %v", got)
+	}
+	if got := uniforms["colors[1]"]; !slices.Equal(got.([]float32), []float32{0, 0, 1}) {
+		t.Errorf("colors[1] = %v", got)
+	}
+	if got := uniforms["tex"]; !slices.Equal(got.([]int32), []int32{3}) {
+		t.Errorf("tex = %v", got)
+	}
+
+	tests := []struct {
+		set       func()
+		wantName  string
+		wantCount int
+	}{
+		{func() { shader.SetVec3("brightness", mgl32.Vec3{}) }, "brightness", 1},
+		{func() { shader.SetInt("colors", 1) }, "colors", 1},
+		{func() { shader.SetFloat("tex", 1) }, "tex", 1},
+		{func() { shader.SetVec3Array("colors[1]", make([]mgl32.Vec3, 2)) }, "colors[1]", 2},
+	}
+	for _, test := range tests {
+		reported = nil
+		test.set()
+
+		var typeErr *UniformTypeError
+		if len(reported) != 1 || !errors.As(reported[0], &typeErr) {
+			t.Errorf("setting %s reported %v, want a UniformTypeError", test.wantName, reported)
+			continue
+		}
+		if typeErr.Name != test.wantName || typeErr.Count != test.wantCount {
+			t.Errorf("got %+v, want %s with %d elements", typeErr, test.wantName, test.wantCount)
+		}
+	}
+	if got := uniforms["brightness"]; !slices.Equal(got.([]float32), []float32{2}) {
+		t.Errorf("a rejected setter changed brightness to %v", got)
+	}
+}
@@ -0,0 +1,218 @@
+package gogl
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// the interleaved layout of a vertex struct
+// computing it doesn't need gl so it can be checked in tests
+type VertexLayout struct {
+	Stride     int32
+	Attributes []VertexAttribute
+}
+
+// works out the attribute of every exported field of V from its type
+// and its vertex tag, e.g.
+//
+//	type Vertex struct {
+//		Pos   mgl32.Vec3
+//		UV    mgl32.Vec2 `vertex:"aTexCoord"`
+//		Color [4]uint8   `vertex:",normalized"`
+//		Bones [4]uint8   `vertex:"aBones,location=5,integer"`
+//		Debug float32    `vertex:"-"`
+//	}
+//
+// the name defaults to the field name starting with a lower case letter.
+// fields without a location follow on from the previous field, starting at 0.
+// scalars and arrays of up to 4 components are supported along with
+// mgl32 matrices which use a location per column
+func VertexLayoutOf[V any]() (VertexLayout, error) {
+	var v V
+	return vertexLayout(reflect.TypeOf(v), 0)
+}
+
+func vertexLayout(t reflect.Type, firstLocation uint32) (VertexLayout, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return VertexLayout{}, fmt.Errorf("vertex type %v isn't a struct", t)
+	}
+
+	layout := VertexLayout{Stride: int32(t.Size())}
+	used := make(map[uint32]string)
+	location := firstLocation
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, skip, err := parseVertexTag(field)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+		if skip {
+			continue
+		}
+		if tag.location >= 0 {
+			location = uint32(tag.location)
+		}
+
+		xtype, components, columns, err := vertexFieldShape(field.Type)
+		if err != nil {
+			return VertexLayout{}, fmt.Errorf("%s.%s: %w", t, field.Name, err)
+		}
+		isFloat := xtype == gl.FLOAT || xtype == gl.DOUBLE
+		if tag.integer && isFloat {
+			return VertexLayout{}, fmt.Errorf("%s.%s: integer attributes need integer components not %s", t, field.Name, field.Type)
+		}
+
+		componentSize := field.Type.Size() / uintptr(components*columns)
+		for column := int32(0); column < columns; column++ {
+			if other, ok := used[location]; ok {
+				return VertexLayout{}, fmt.Errorf("%s.%s: location %d is already used by %s", t, field.Name, location, other)
+			}
+			used[location] = field.Name
+
+			layout.Attributes = append(layout.Attributes, VertexAttribute{
+				Name:       tag.name,
+				Location:   location,
+				Components: components,
+				Type:       xtype,
+				Normalized: tag.normalized && !isFloat,
+				Integer:    tag.integer,
+				Stride:     layout.Stride,
+				Offset:     field.Offset + uintptr(column*components)*componentSize,
+				column:     uint32(column),
+			})
+			location++
+		}
+	}
+	return layout, nil
+}
+
+type vertexTag struct {
+	name       string
+	location   int
+	normalized bool
+	integer    bool
+}
+
+// reads a tag like vertex:"name,location=2,normalized,integer"
+func parseVertexTag(field reflect.StructField) (vertexTag, bool, error) {
+	tag := vertexTag{location: -1}
+	if !field.IsExported() {
+		return tag, true, nil
+	}
+
+	value := field.Tag.Get("vertex")
+	if value == "-" {
+		return tag, true, nil
+	}
+
+	options := strings.Split(value, ",")
+	tag.name = options[0]
+	if tag.name == "" {
+		tag.name = glslFieldName(field.Name)
+	}
+	for _, option := range options[1:] {
+		switch key, value, _ := strings.Cut(option, "="); key {
+		case "location":
+			location, err := strconv.Atoi(value)
+			if err != nil || location < 0 {
+				return tag, false, fmt.Errorf("invalid location %q", value)
+			}
+			tag.location = location
+		case "normalized":
+			tag.normalized = true
+		case "integer":
+			tag.integer = true
+		default:
+			return tag, false, fmt.Errorf("unknown vertex tag option %q", option)
+		}
+	}
+	return tag, false, nil
+}
+
+// the component type, number of components per location and number of locations of a field
+func vertexFieldShape(t reflect.Type) (xtype uint32, components, columns int32, err error) {
+	if columns, ok := std140MatrixSize(t); ok {
+		return gl.FLOAT, int32(columns), int32(columns), nil
+	}
+
+	components = 1
+	if t.Kind() == reflect.Array {
+		if t.Len() < 1 || t.Len() > 4 {
+			return 0, 0, 0, fmt.Errorf("arrays of %d components aren't supported, attributes have 1 to 4", t.Len())
+		}
+		components = int32(t.Len())
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Int8:
+		xtype = gl.BYTE
+	case reflect.Uint8:
+		xtype = gl.UNSIGNED_BYTE
+	case reflect.Int16:
+		xtype = gl.SHORT
+	case reflect.Uint16:
+		xtype = gl.UNSIGNED_SHORT
+	case reflect.Int32:
+		xtype = gl.INT
+	case reflect.Uint32:
+		xtype = gl.UNSIGNED_INT
+	case reflect.Float32:
+		xtype = gl.FLOAT
+	case reflect.Float64:
+		xtype = gl.DOUBLE
+	default:
+		return 0, 0, 0, fmt.Errorf("unsupported vertex component type %s", t)
+	}
+	return xtype, components, 1, nil
+}
+
+// uploads vertices into the bound array buffer and adds an attribute
+// for each field of V to the vertex array at id. Fields without a location
+// follow on from the loader's previous attributes
+func BuildStructBuffer[V any](b *BufferLoader, id BufferID, vertices []V) error {
+	var v V
+	layout, err := vertexLayout(reflect.TypeOf(v), b.layoutIndex)
+	if err != nil {
+		return err
+	}
+
+	BufferData(gl.ARRAY_BUFFER, vertices, gl.STATIC_DRAW)
+	b.addAttributes(id, layout, 0)
+	return nil
+}
+
+// like BuildStructBuffer but in a new buffer that advances once per
+// instance instead of once per vertex. The returned buffer can be
+// updated with new instances, e.g. with SetBufferData
+func BuildInstanceBuffer[V any](b *BufferLoader, id BufferID, instances []V) (*Buffer, error) {
+	var v V
+	layout, err := vertexLayout(reflect.TypeOf(v), b.layoutIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := NewBufferWithData(gl.ARRAY_BUFFER, instances, gl.DYNAMIC_DRAW)
+	b.addAttributes(id, layout, 1)
+	return buffer, nil
+}
+
+// points the attributes of layout at the bound array buffer
+func (b *BufferLoader) addAttributes(id BufferID, layout VertexLayout, divisor uint32) {
+	BindVertexArray(id)
+
+	var buffer int32
+	ogl.GetIntegerv(gl.ARRAY_BUFFER_BINDING, &buffer)
+
+	for _, attribute := range layout.Attributes {
+		attribute.Divisor = divisor
+		attribute.buffer = BufferID(buffer)
+		attribute.apply(attribute.Location)
+		b.attributes = append(b.attributes, attribute)
+		b.layoutIndex = max(b.layoutIndex, attribute.Location+1)
+	}
+}
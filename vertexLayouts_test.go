@@ -0,0 +1,159 @@
+package gogl
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+type testVertex struct {
+	Pos   mgl32.Vec3
+	UV    mgl32.Vec2 `vertex:"aTexCoord"`
+	Color [4]uint8   `vertex:",normalized"`
+	Bones [4]uint8   `vertex:"aBones,location=5,integer"`
+	Debug float32    `vertex:"-"`
+	Next  int16
+	local float32
+}
+
+func TestVertexLayoutOf(t *testing.T) {
+	layout, err := VertexLayoutOf[testVertex]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//skipped and unexported fields still take up space in the stride
+	if layout.Stride != 40 {
+		t.Errorf("stride = %d, want 40", layout.Stride)
+	}
+	want := []VertexAttribute{
+		{Name: "pos", Location: 0, Components: 3, Type: gl.FLOAT, Stride: 40, Offset: 0},
+		{Name: "aTexCoord", Location: 1, Components: 2, Type: gl.FLOAT, Stride: 40, Offset: 12},
+		{Name: "color", Location: 2, Components: 4, Type: gl.UNSIGNED_BYTE, Normalized: true, Stride: 40, Offset: 20},
+		{Name: "aBones", Location: 5, Components: 4, Type: gl.UNSIGNED_BYTE, Integer: true, Stride: 40, Offset: 24},
+		//carries on after the explicit location
+		{Name: "next", Location: 6, Components: 1, Type: gl.SHORT, Stride: 40, Offset: 32},
+	}
+	if !slices.Equal(layout.Attributes, want) {
+		t.Errorf("attributes are\n%+v\nwant\n%+v", layout.Attributes, want)
+	}
+}
+
+func TestVertexLayoutMatrixColumns(t *testing.T) {
+	type instance struct {
+		Model  mgl32.Mat4
+		Normal mgl32.Mat3 `vertex:",location=8"`
+		Tint   mgl32.Vec4
+	}
+	layout, err := VertexLayoutOf[instance]()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type column struct {
+		name       string
+		location   uint32
+		components int32
+		offset     uintptr
+		column     uint32
+	}
+	var got []column
+	for _, a := range layout.Attributes {
+		got = append(got, column{a.Name, a.Location, a.Components, a.Offset, a.column})
+	}
+	want := []column{
+		{"model", 0, 4, 0, 0}, {"model", 1, 4, 16, 1}, {"model", 2, 4, 32, 2}, {"model", 3, 4, 48, 3},
+		{"normal", 8, 3, 64, 0}, {"normal", 9, 3, 76, 1}, {"normal", 10, 3, 88, 2},
+		{"tint", 11, 4, 100, 0},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("columns are\n%v\nwant\n%v", got, want)
+	}
+	if layout.Stride != 116 {
+		t.Errorf("stride = %d, want 116", layout.Stride)
+	}
+}
+
+func TestVertexLayoutErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		layout    func() (VertexLayout, error)
+		wantError string
+	}{
+		{
+			name: "explicit location collision",
+			layout: VertexLayoutOf[struct {
+				Pos mgl32.Vec3
+				UV  mgl32.Vec2 `vertex:",location=0"`
+			}],
+			wantError: "location 0 is already used by Pos",
+		},
+		{
+			name: "matrix column collision",
+			layout: VertexLayoutOf[struct {
+				Model mgl32.Mat4
+				Color mgl32.Vec4 `vertex:",location=2"`
+			}],
+			wantError: "location 2 is already used by Model",
+		},
+		{
+			name: "integer float",
+			layout: VertexLayoutOf[struct {
+				Weights mgl32.Vec4 `vertex:",integer"`
+			}],
+			wantError: "integer attributes need integer components not mgl32.Vec4",
+		},
+		{
+			name: "integer double",
+			layout: VertexLayoutOf[struct {
+				Height float64 `vertex:",integer"`
+			}],
+			wantError: "integer attributes need integer components not float64",
+		},
+		{
+			name: "invalid location",
+			layout: VertexLayoutOf[struct {
+				Pos mgl32.Vec3 `vertex:",location=-1"`
+			}],
+			wantError: `invalid location "-1"`,
+		},
+		{
+			name: "unknown option",
+			layout: VertexLayoutOf[struct {
+				Pos mgl32.Vec3 `vertex:",flat"`
+			}],
+			wantError: `unknown vertex tag option "flat"`,
+		},
+		{
+			name: "too many components",
+			layout: VertexLayoutOf[struct {
+				Weights [5]float32
+			}],
+			wantError: "arrays of 5 components aren't supported",
+		},
+		{
+			name: "unsupported type",
+			layout: VertexLayoutOf[struct {
+				Name string
+			}],
+			wantError: "unsupported vertex component type string",
+		},
+		{
+			name:      "not a struct",
+			layout:    VertexLayoutOf[mgl32.Vec3],
+			wantError: "isn't a struct",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.layout()
+			if err == nil || !strings.Contains(err.Error(), test.wantError) {
+				t.Errorf("got error %v, want one containing %q", err, test.wantError)
+			}
+		})
+	}
+}
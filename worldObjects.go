@@ -1,6 +1,11 @@
 package gogl
 
 import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/mathgl/mgl32"
 )
@@ -9,26 +14,110 @@ type Object struct {
 	Type         string
 	Verticies    []float32 //in XYZ UV
 	VertexStride int       // 5 if using XYZ UV
-	normals      []float32
-	bufferLoader *BufferLoader
-	vao          BufferID
-	nao          BufferID
+	Indices      []uint32  //optional, drawn with an element buffer when set
+	// the shader inputs for the position, uv and normal used by
+	// BindToShader, DefaultAttributeNames when empty
+	AttributeNames []string
+	normals        []float32
+	bufferLoader   *BufferLoader
+	vao            BufferID
+	vbo            BufferID
+	nao            BufferID
+	ebo            BufferID
+	indexType      uint32
+	// shared between copies of the Object like its buffers are
+	instancing *objectInstances
+}
+
+type objectInstances struct {
+	buffer       *Buffer
+	count        int32
+	instanceType reflect.Type
 }
 
+// the per instance data used by EnableInstancing
+type ModelInstance struct {
+	Model mgl32.Mat4 `vertex:"aModel"`
+}
+
+// the names BindToShader looks for when an Object has no AttributeNames
+var DefaultAttributeNames = []string{"aPos", "aTexCoord", "aNormal"}
+
 func (o *Object) FillBuffers() {
 	o.bufferLoader = NewBufferLoader()
 	o.vao = GenBindVertexArray()
 	o.nao = GenBindBuffer(gl.ARRAY_BUFFER)
 
-	GenBindBuffer(gl.ARRAY_BUFFER) //VBO
+	o.vbo = GenBindBuffer(gl.ARRAY_BUFFER)
 
 	BindVertexArray(o.vao)
-	o.bufferLoader.BuildFloatBuffer(o.vao, NewBufferLayout([]int32{3, 2}, o.Verticies))
-	gl.BindBuffer(gl.ARRAY_BUFFER, uint32(o.nao))
-	o.bufferLoader.BuildFloatBuffer(o.nao, NewBufferLayout([]int32{3}, o.normals))
+	names := o.attributeNames()
+	o.bufferLoader.BuildFloatBuffer(o.vao, NewBufferLayout([]int32{3, 2}, o.Verticies).Named(names[0], names[1]))
+	ogl.BindBuffer(gl.ARRAY_BUFFER, uint32(o.nao))
+	o.bufferLoader.BuildFloatBuffer(o.vao, NewBufferLayout([]int32{3}, o.normals).Named(names[2]))
+
+	if len(o.Indices) > 0 {
+		o.fillIndexBuffer()
+	}
+}
+
+// uploads the indices as uint16 when every index fits
+// as that halves the size of the element buffer
+func (o *Object) fillIndexBuffer() {
+	var maxIndex uint32
+	for _, i := range o.Indices {
+		maxIndex = max(maxIndex, i)
+	}
+
+	if maxIndex <= math.MaxUint16 {
+		indices := make([]uint16, len(o.Indices))
+		for i, index := range o.Indices {
+			indices[i] = uint16(index)
+		}
+		o.ebo = o.bufferLoader.BuildUint16IndexBuffer(o.vao, indices)
+		o.indexType = gl.UNSIGNED_SHORT
+	} else {
+		o.ebo = o.bufferLoader.BuildUint32IndexBuffer(o.vao, o.Indices)
+		o.indexType = gl.UNSIGNED_INT
+	}
+}
+
+// frees the vertex array and every buffer of the object. Copies of the
+// object share them so only one copy should be deleted
+func (o *Object) Delete() {
+	o.vao.DeleteVertexArray()
+	o.vbo.Delete()
+	o.nao.Delete()
+	o.ebo.Delete()
+	if o.instancing != nil {
+		o.instancing.buffer.Delete()
+		o.instancing = nil
+	}
+	o.vao, o.vbo, o.nao, o.ebo = 0, 0, 0, 0
+	o.bufferLoader = nil
+}
+
+func (o *Object) attributeNames() []string {
+	names := o.AttributeNames
+	if len(names) == 0 {
+		names = DefaultAttributeNames
+	}
+	return append(names[:len(names):len(names)], make([]string, max(3-len(names), 0))...)
+}
+
+// moves the position, uv and normal attributes to the locations the shader
+// has for them by name and checks they match the shader's inputs
+// e.g. a position going to a vec2 input gives an error
+func (o *Object) BindToShader(shader Shader) error {
+	return o.bufferLoader.BindToShader(o.vao, shader)
 }
 
 func (o *Object) CalcNormals(triangleCount int) {
+	if len(o.Indices) > 0 {
+		o.calcIndexedNormals(triangleCount)
+		return
+	}
+
 	vertexCount := triangleCount * 3 //3 bc we are working in 3d space so XYZ
 
 	o.normals = make([]float32, vertexCount*3)
@@ -55,22 +144,144 @@ func (o *Object) CalcNormals(triangleCount int) {
 	}
 }
 
+// indexed vertices can be shared between triangles so each vertex
+// gets the average normal of every triangle it is part of
+func (o *Object) calcIndexedNormals(triangleCount int) {
+	o.normals = make([]float32, len(o.Verticies)/o.VertexStride*3)
+
+	vertex := func(i uint32) mgl32.Vec3 {
+		index := int(i) * o.VertexStride
+		return mgl32.Vec3{o.Verticies[index], o.Verticies[index+1], o.Verticies[index+2]}
+	}
+
+	for tri := 0; tri < triangleCount; tri++ {
+		i1, i2, i3 := o.Indices[tri*3], o.Indices[tri*3+1], o.Indices[tri*3+2]
+		normal := TriangleNormal(vertex(i1), vertex(i2), vertex(i3))
+
+		for _, i := range [3]uint32{i1, i2, i3} {
+			o.normals[i*3+0] += normal.X()
+			o.normals[i*3+1] += normal.Y()
+			o.normals[i*3+2] += normal.Z()
+		}
+	}
+
+	for i := 0; i < len(o.normals); i += 3 {
+		normal := mgl32.Vec3{o.normals[i], o.normals[i+1], o.normals[i+2]}
+		if normal.Len() > 0 {
+			normal = normal.Normalize()
+		}
+		o.normals[i], o.normals[i+1], o.normals[i+2] = normal.X(), normal.Y(), normal.Z()
+	}
+}
+
 func (o Object) Draw(shader Shader, drawMatrix mgl32.Mat4) {
 	BindVertexArray(o.vao)
 
 	shader.SetMatrix4("model", drawMatrix)
-	gl.DrawArrays(gl.TRIANGLES, 0, int32(len(o.Verticies)/o.VertexStride))
+	o.drawCall()
 }
 
+// with instancing enabled by EnableInstancing every matrix is uploaded
+// and drawn in one call, otherwise the "model" uniform is set for each
 func (o Object) DrawMultiple(shader Shader, num int, drawMatrix func(int) mgl32.Mat4) {
 	BindVertexArray(o.vao)
 
+	if o.instancing != nil && o.instancing.instanceType == reflect.TypeOf(ModelInstance{}) {
+		instances := make([]ModelInstance, num)
+		for i := range instances {
+			instances[i].Model = drawMatrix(i)
+		}
+		if err := SetObjectInstances(&o, instances); err == nil {
+			o.DrawInstanced(shader)
+			return
+		}
+	}
+
 	for i := 0; i < num; i++ {
 		shader.SetMatrix4("model", drawMatrix(i))
-		gl.DrawArrays(gl.TRIANGLES, 0, int32(len(o.Verticies)/o.VertexStride))
+		o.drawCall()
 	}
 }
 
+// adds an "aModel" mat4 attribute that advances once per instance so
+// the shader reads the model matrix from it instead of a uniform
+// it takes four locations after the position, uv and normal attributes
+// and fails if FillBuffers hasn't been called yet
+func (o *Object) EnableInstancing() error {
+	return EnableObjectInstancing[ModelInstance](o)
+}
+
+// like EnableInstancing but with a custom per instance struct, laid out
+// like BuildStructBuffer e.g. a model matrix along with a colour
+func EnableObjectInstancing[V any](o *Object) error {
+	if o.vao == 0 {
+		return errors.New("the object's buffers haven't been filled")
+	}
+	buffer, err := BuildInstanceBuffer[V](o.bufferLoader, o.vao, nil)
+	if err != nil {
+		return err
+	}
+
+	var v V
+	o.instancing = &objectInstances{
+		buffer:       buffer,
+		instanceType: reflect.TypeOf(v),
+	}
+	return nil
+}
+
+// uploads the data for every instance, V has to be
+// the type instancing was enabled with
+func SetObjectInstances[V any](o *Object, instances []V) error {
+	var v V
+	if o.instancing == nil {
+		return errors.New("instancing isn't enabled on the object")
+	}
+	if t := reflect.TypeOf(v); t != o.instancing.instanceType {
+		return fmt.Errorf("the object's instances are %s not %s", o.instancing.instanceType, t)
+	}
+
+	SetBufferData(o.instancing.buffer, instances)
+	o.instancing.count = int32(len(instances))
+	return nil
+}
+
+// uploads a model matrix for every instance after EnableInstancing
+// it fails like SetObjectInstances if instancing isn't enabled
+// or was enabled with a type other than ModelInstance
+func (o *Object) SetInstanceModels(models []mgl32.Mat4) error {
+	instances := make([]ModelInstance, len(models))
+	for i, model := range models {
+		instances[i].Model = model
+	}
+	return SetObjectInstances(o, instances)
+}
+
+// draws every instance uploaded with SetInstanceModels or SetObjectInstances in one call
+func (o Object) DrawInstanced(shader Shader) {
+	if o.instancing == nil || o.instancing.count == 0 {
+		return
+	}
+	BindVertexArray(o.vao)
+
+	count := o.instancing.count
+	if o.ebo != 0 {
+		ogl.DrawElementsInstanced(gl.TRIANGLES, int32(len(o.Indices)), o.indexType, gl.PtrOffset(0), count)
+		return
+	}
+	ogl.DrawArraysInstanced(gl.TRIANGLES, 0, int32(len(o.Verticies)/o.VertexStride), count)
+}
+
+// uses gl.DrawElements when the object has an element buffer
+// otherwise falls back to drawing the flat vertex list
+func (o Object) drawCall() {
+	if o.ebo != 0 {
+		ogl.DrawElementsWithOffset(gl.TRIANGLES, int32(len(o.Indices)), o.indexType, 0)
+		return
+	}
+	ogl.DrawArrays(gl.TRIANGLES, 0, int32(len(o.Verticies)/o.VertexStride))
+}
+
 func Cube(size float32) Object {
 	o := Object{
 		Type: "cube",
@@ -126,6 +337,59 @@ func Cube(size float32) Object {
 	return o
 }
 
+// same as Cube but only stores the 4 corners of each face
+// and draws them through an element buffer
+func IndexedCube(size float32) Object {
+	o := Object{
+		Type: "cube",
+	}
+	o.Verticies = []float32{
+		-size / 2, -size / 2, size / 2, 0.0, 0.0,
+		size / 2, -size / 2, size / 2, 1.0, 0.0,
+		size / 2, size / 2, size / 2, 1.0, 1.0,
+		-size / 2, size / 2, size / 2, 0.0, 1.0,
+
+		size / 2, -size / 2, -size / 2, 0.0, 0.0,
+		-size / 2, -size / 2, -size / 2, 1.0, 0.0,
+		-size / 2, size / 2, -size / 2, 1.0, 1.0,
+		size / 2, size / 2, -size / 2, 0.0, 1.0,
+
+		size / 2, -size / 2, size / 2, 0.0, 0.0,
+		size / 2, -size / 2, -size / 2, 1.0, 0.0,
+		size / 2, size / 2, -size / 2, 1.0, 1.0,
+		size / 2, size / 2, size / 2, 0.0, 1.0,
+
+		-size / 2, -size / 2, -size / 2, 0.0, 0.0,
+		-size / 2, -size / 2, size / 2, 1.0, 0.0,
+		-size / 2, size / 2, size / 2, 1.0, 1.0,
+		-size / 2, size / 2, -size / 2, 0.0, 1.0,
+
+		-size / 2, size / 2, size / 2, 0.0, 0.0,
+		size / 2, size / 2, size / 2, 1.0, 0.0,
+		size / 2, size / 2, -size / 2, 1.0, 1.0,
+		-size / 2, size / 2, -size / 2, 0.0, 1.0,
+
+		-size / 2, -size / 2, -size / 2, 0.0, 0.0,
+		size / 2, -size / 2, -size / 2, 1.0, 0.0,
+		size / 2, -size / 2, size / 2, 1.0, 1.0,
+		-size / 2, -size / 2, size / 2, 0.0, 1.0,
+	}
+	o.VertexStride = 5
+
+	o.Indices = make([]uint32, 0, 36)
+	for face := uint32(0); face < 6; face++ {
+		o.Indices = append(o.Indices,
+			face*4+0, face*4+1, face*4+2,
+			face*4+2, face*4+3, face*4+0,
+		)
+	}
+
+	o.CalcNormals(12)
+	o.FillBuffers()
+
+	return o
+}
+
 func Pentahedron(size float32) Object {
 	o := Object{
 		Type: "pentahedron",
@@ -0,0 +1,155 @@
+package gogl
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+func TestFillBuffers(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore) //after the object is deleted
+
+	o := testTriangle(t)
+	vao := fake.VertexArray(o.vao)
+	if vao == nil {
+		t.Fatal("no vertex array was made")
+	}
+
+	tests := []struct {
+		location   uint32
+		buffer     BufferID
+		components int32
+		stride     int32
+		offset     uintptr
+	}{
+		{0, o.vbo, 3, 20, 0},  //aPos
+		{1, o.vbo, 2, 20, 12}, //aTexCoord
+		{2, o.nao, 3, 12, 0},  //aNormal
+	}
+	for _, test := range tests {
+		a := vao.Attributes[test.location]
+		if a == nil || !a.Enabled || a.Buffer != uint32(test.buffer) || a.Type != gl.FLOAT ||
+			a.Components != test.components || a.Stride != test.stride || a.Offset != test.offset {
+			t.Errorf("attribute %d = %+v, want %+v", test.location, a, test)
+		}
+	}
+
+	if got := fake.Buffer(o.vbo).Data; !slices.Equal(got, floatBytes(o.Verticies)) {
+		t.Errorf("vertex buffer holds % x", got)
+	}
+	if got := fake.Buffer(o.nao).Data; !slices.Equal(got, floatBytes(o.normals)) {
+		t.Errorf("normal buffer holds % x", got)
+	}
+	if vao.ElementBuffer != uint32(o.ebo) || o.indexType != gl.UNSIGNED_SHORT {
+		t.Errorf("element buffer %d of type 0x%X, want %d of uint16s", vao.ElementBuffer, o.indexType, o.ebo)
+	}
+	if got := fake.Buffer(o.ebo).Data; !slices.Equal(got, []byte{0, 0, 1, 0, 2, 0}) {
+		t.Errorf("element buffer holds % x", got)
+	}
+}
+
+func TestIndexedObjects(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore) //after the objects are deleted
+
+	cube := IndexedCube(2)
+	t.Cleanup(cube.Delete)
+	if len(cube.normals) != 24*3 {
+		t.Fatalf("%d normals for 24 vertices", len(cube.normals)/3)
+	}
+	//the shared corners of the front face still point straight out of it
+	for i := 0; i < 4; i++ {
+		if normal := mgl32.Vec3(cube.normals[i*3 : i*3+3]); !normal.ApproxEqual(mgl32.Vec3{0, 0, 1}) {
+			t.Errorf("front face vertex %d has normal %v", i, normal)
+		}
+	}
+
+	shader := testShader(t, testVertexShader)
+	fake.Reset()
+	cube.Draw(shader, mgl32.Ident4())
+	draws := fake.CallsTo("DrawElementsWithOffset")
+	if len(draws) != 1 || draws[0].Args[1] != int32(36) || draws[0].Args[2] != uint32(gl.UNSIGNED_SHORT) {
+		t.Errorf("drew the cube with %v, want 36 uint16 indices", draws)
+	}
+	if len(fake.CallsTo("DrawArrays")) != 0 {
+		t.Error("an indexed object was drawn with DrawArrays")
+	}
+
+	//an index past the uint16 range needs the whole uint32
+	large := &Object{
+		Verticies:    make([]float32, 70001*5),
+		VertexStride: 5,
+		Indices:      []uint32{0, 1, 70000},
+	}
+	large.CalcNormals(1)
+	large.FillBuffers()
+	t.Cleanup(large.Delete)
+	if large.indexType != gl.UNSIGNED_INT {
+		t.Errorf("index type 0x%X, want uint32s", large.indexType)
+	}
+	if got := fake.Buffer(large.ebo).Data; !slices.Equal(got, []byte{0, 0, 0, 0, 1, 0, 0, 0, 0x70, 0x11, 1, 0}) {
+		t.Errorf("element buffer holds % x", got)
+	}
+}
+
+func TestSetInstanceModels(t *testing.T) {
+	fake, restore := UseRecordingGL()
+	t.Cleanup(restore) //after the objects are deleted
+
+	unfilled := &Object{Verticies: []float32{0, 0, 0, 0, 0}, VertexStride: 5}
+	if err := unfilled.EnableInstancing(); err == nil || !strings.Contains(err.Error(), "haven't been filled") {
+		t.Errorf("got error %v before FillBuffers", err)
+	}
+
+	o := testTriangle(t)
+	if err := o.SetInstanceModels([]mgl32.Mat4{mgl32.Ident4()}); err == nil || !strings.Contains(err.Error(), "instancing isn't enabled") {
+		t.Errorf("got error %v before enabling instancing", err)
+	}
+
+	type coloured struct {
+		Model mgl32.Mat4
+		Color mgl32.Vec4
+	}
+	other := testTriangle(t)
+	if err := EnableObjectInstancing[coloured](other); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SetInstanceModels([]mgl32.Mat4{mgl32.Ident4()}); err == nil || !strings.Contains(err.Error(), "instances are") {
+		t.Errorf("got error %v with a custom instance type", err)
+	}
+
+	if err := o.EnableInstancing(); err != nil {
+		t.Fatal(err)
+	}
+	models := []mgl32.Mat4{mgl32.Translate3D(1, 2, 3), mgl32.Scale3D(2, 2, 2)}
+	if err := o.SetInstanceModels(models); err != nil {
+		t.Fatal(err)
+	}
+	data := fake.Buffer(o.instancing.buffer.ID()).Data
+	if len(data) < 2*64 {
+		t.Fatalf("the instance buffer holds %d bytes", len(data))
+	}
+	//the translation is in the last column of the first matrix
+	if x := math.Float32frombits(binary.LittleEndian.Uint32(data[48:])); x != 1 {
+		t.Errorf("translation x = %v, want 1", x)
+	}
+
+	shader, err := NewEmbeddedShaderErr(testVertexShader, testFragmentShader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer shader.Delete()
+
+	fake.Reset()
+	o.DrawMultiple(shader, 3, func(i int) mgl32.Mat4 { return mgl32.Translate3D(float32(i), 0, 0) })
+	draws := fake.CallsTo("DrawElementsInstanced")
+	if len(draws) != 1 || draws[0].Args[4] != int32(3) {
+		t.Errorf("DrawMultiple drew with %v, want one call of 3 instances", draws)
+	}
+}